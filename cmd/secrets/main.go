@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/whiterage/14-11-2025/internal/secrets"
+)
+
+const usage = `usage:
+  secrets set <name>     read the value from stdin and store it
+  secrets delete <name>  remove the secret
+  secrets list           print stored secret names`
+
+func main() {
+	path := os.Getenv("SECRETS_PATH")
+	if path == "" {
+		path = filepath.Join("storage", "secrets.enc")
+	}
+
+	key := os.Getenv("SECRETS_KEY")
+	if key == "" {
+		log.Fatal("SECRETS_KEY is required")
+	}
+
+	store, err := secrets.Open(path, key)
+	if err != nil {
+		log.Fatalf("open secrets: %v", err)
+	}
+
+	args := os.Args[1:]
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		for _, name := range store.Names() {
+			fmt.Println(name)
+		}
+	case args[0] == "set" && len(args) == 2:
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && value == "" {
+			log.Fatalf("read value: %v", err)
+		}
+		if err := store.Set(args[1], strings.TrimRight(value, "\r\n")); err != nil {
+			log.Fatalf("save secret: %v", err)
+		}
+	case args[0] == "delete" && len(args) == 2:
+		if err := store.Delete(args[1]); err != nil {
+			log.Fatalf("delete secret: %v", err)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/whiterage/14-11-2025/internal/agent"
+)
+
+// runAgent checks links for one location on behalf of a remote server:
+// AGENT_SERVER_URL=https://checker.internal AGENT_LOCATION=dmz AGENT_TOKEN=... server agent
+func runAgent() {
+	server := os.Getenv("AGENT_SERVER_URL")
+	location := os.Getenv("AGENT_LOCATION")
+	token := os.Getenv("AGENT_TOKEN")
+	if server == "" || location == "" || token == "" {
+		log.Fatal("agent: AGENT_SERVER_URL, AGENT_LOCATION and AGENT_TOKEN are required")
+	}
+
+	concurrency, _ := strconv.Atoi(os.Getenv("AGENT_CONCURRENCY"))
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	_, registry := buildCheckers(openSecrets())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("agent: checking links for location %q via %s", location, server)
+	_ = agent.New(server, location, token, registry, concurrency).Run(ctx)
+	log.Println("agent: stopped")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/secrets"
+	"github.com/whiterage/14-11-2025/internal/worker"
+)
+
+// openSecrets opens the encrypted secret store, or returns nil when
+// SECRETS_KEY is not set.
+func openSecrets() *secrets.Store {
+	key := os.Getenv("SECRETS_KEY")
+	if key == "" {
+		return nil
+	}
+	secretsPath := os.Getenv("SECRETS_PATH")
+	if secretsPath == "" {
+		secretsPath = filepath.Join("storage", "secrets.enc")
+	}
+	store, err := secrets.Open(secretsPath, key)
+	if err != nil {
+		log.Fatalf("init secrets: %v", err)
+	}
+	return store
+}
+
+// buildCheckers wires the per-scheme checkers shared by the server and
+// agent modes.
+func buildCheckers(store *secrets.Store) (*worker.HTTPChecker, *worker.Registry) {
+	var checkerOpts []worker.Option
+	if store != nil {
+		checkerOpts = append(checkerOpts, worker.WithSecrets(store))
+	}
+
+	checkerOpts = append(checkerOpts, worker.WithUserAgent(os.Getenv("CHECKER_USER_AGENT")))
+	if enabled, _ := strconv.ParseBool(os.Getenv("ROBOTS_MODE")); enabled {
+		checkerOpts = append(checkerOpts, worker.WithRobots(envDuration("ROBOTS_TTL", time.Hour)))
+	}
+
+	httpChecker := worker.NewHTTPChecker(5*time.Second, checkerOpts...)
+
+	registry := worker.NewRegistry()
+	transactionChecker := worker.NewTransactionChecker(httpChecker)
+	registry.Register("http", transactionChecker)
+	registry.Register("https", transactionChecker)
+	registry.Register("ws", worker.CheckerFunc(httpChecker.CheckWebSocket))
+	registry.Register("wss", worker.CheckerFunc(httpChecker.CheckWebSocket))
+	smtpChecker := worker.NewSMTPChecker(10*time.Second, os.Getenv("SMTP_HELO_NAME"))
+	registry.Register("smtp", smtpChecker)
+	registry.Register("smtps", smtpChecker)
+	registry.Register("ftp", worker.NewFTPChecker(5*time.Second))
+	grpcChecker := worker.NewGRPCChecker(5*time.Second, os.Getenv("CHECKER_USER_AGENT"))
+	registry.Register("grpc", grpcChecker)
+	registry.Register("grpcs", grpcChecker)
+
+	return httpChecker, registry
+}
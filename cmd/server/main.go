@@ -7,16 +7,21 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"syscall"
 	"time"
 
 	api "github.com/whiterage/14-11-2025/internal/http"
 	"github.com/whiterage/14-11-2025/internal/repository"
 	"github.com/whiterage/14-11-2025/internal/service"
-	"github.com/whiterage/14-11-2025/internal/worker"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "agent" {
+		runAgent()
+		return
+	}
+
 	storagePath := os.Getenv("TASK_STORAGE_PATH")
 	if storagePath == "" {
 		storagePath = filepath.Join("storage", "tasks.json")
@@ -27,9 +32,63 @@ func main() {
 		log.Fatalf("init repository: %v", err)
 	}
 
-	checker := worker.NewHTTPChecker(5 * time.Second)
-	svc := service.NewService(repo, checker, 20)
-	pool := service.NewWorkerPool(svc, 4)
+	store := openSecrets()
+	httpChecker, registry := buildCheckers(store)
+
+	var checker service.Checker = registry
+	if ttl := envDuration("CHECK_CACHE_TTL", 0); ttl > 0 {
+		checker = service.NewCachedChecker(checker, ttl)
+	}
+
+	sitemapLimit, _ := strconv.Atoi(os.Getenv("SITEMAP_MAX_URLS"))
+	serviceOpts := []service.Option{
+		service.WithFetcher(httpChecker),
+		service.WithSitemapLimit(sitemapLimit),
+		service.WithQueueAging(envDuration("QUEUE_AGING", time.Minute)),
+		service.WithIdempotencyTTL(envDuration("IDEMPOTENCY_TTL", 24*time.Hour)),
+	}
+	// Webhooks are signed with secrets from the store, so they need it.
+	var webhooks *service.WebhookOutbox
+	if store != nil {
+		serviceOpts = append(serviceOpts, service.WithSecrets(store))
+		webhooks = service.NewWebhookOutbox(store)
+		serviceOpts = append(serviceOpts, service.WithWebhooks(webhooks))
+	}
+	if spool, _ := strconv.Atoi(os.Getenv("QUEUE_SPOOL_MAX")); spool > 0 {
+		serviceOpts = append(serviceOpts, service.WithSpool(spool))
+	}
+	var agents *service.AgentHub
+	if token := os.Getenv("AGENT_TOKEN"); token != "" {
+		agents = service.NewAgentHub(token, envDuration("AGENT_LEASE_TTL", time.Minute))
+		serviceOpts = append(serviceOpts, service.WithAgents(agents))
+	}
+
+	queueSize, _ := strconv.Atoi(os.Getenv("QUEUE_SIZE"))
+	if queueSize <= 0 {
+		queueSize = 20
+	}
+	svc := service.NewService(repo, checker, queueSize, serviceOpts...)
+	workers, _ := strconv.Atoi(os.Getenv("WORKERS"))
+	if workers <= 0 {
+		workers = 4
+	}
+	pool := service.NewWorkerPool(svc, workers)
+	var autoscaler *service.Autoscaler
+	if maxWorkers, _ := strconv.Atoi(os.Getenv("AUTOSCALE_MAX")); maxWorkers > 0 {
+		minWorkers, _ := strconv.Atoi(os.Getenv("AUTOSCALE_MIN"))
+		if minWorkers <= 0 {
+			minWorkers = 1
+		}
+		autoscaler, err = service.NewAutoscaler(pool, service.AutoscaleConfig{
+			Min:      minWorkers,
+			Max:      maxWorkers,
+			Interval: envDuration("AUTOSCALE_INTERVAL", 10*time.Second),
+			Drain:    envDuration("AUTOSCALE_DRAIN", 30*time.Second),
+		})
+		if err != nil {
+			log.Fatalf("init autoscaler: %v", err)
+		}
+	}
 	handlers := api.NewHandlers(svc)
 	mux := http.NewServeMux()
 
@@ -46,7 +105,22 @@ func main() {
 
 	pool.Start(workerCtx)
 
+	// Background loops create or resize work; they stop before the queue
+	// is closed on shutdown.
+	bgCtx, stopBackground := context.WithCancel(workerCtx)
+	defer stopBackground()
+	if autoscaler != nil {
+		go autoscaler.Run(bgCtx)
+	}
+	go svc.RunMonitors(bgCtx)
+	if webhooks != nil {
+		go webhooks.Run(workerCtx)
+	}
+
 	handlers.Register(mux)
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		api.NewAdminHandlers(pool, autoscaler, token).Register(mux)
+	}
 
 	server := &http.Server{Addr: ":8080", Handler: mux}
 
@@ -59,6 +133,9 @@ func main() {
 	<-ctx.Done()
 
 	log.Println("shutdown: stopping http server...")
+	if agents != nil {
+		agents.Close()
+	}
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -67,6 +144,7 @@ func main() {
 		log.Printf("shutdown error: %v", err)
 	}
 
+	stopBackground()
 	svc.CloseQueue()
 
 	done := make(chan struct{})
@@ -84,3 +162,16 @@ func main() {
 
 	log.Println("shutdown: complete")
 }
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("config: invalid %s=%q, using %v", name, value, fallback)
+		return fallback
+	}
+	return d
+}
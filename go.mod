@@ -2,4 +2,16 @@ module github.com/whiterage/14-11-2025
 
 go 1.25.1
 
-require github.com/jung-kurt/gofpdf v1.16.2
+require (
+	github.com/jung-kurt/gofpdf v1.16.2
+	golang.org/x/crypto v0.44.0
+	golang.org/x/net v0.47.0
+	google.golang.org/grpc v1.77.0
+)
+
+require (
+	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251022142026-3a174f9686a8 // indirect
+	google.golang.org/protobuf v1.36.10 // indirect
+)
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var errUnknownAgent = errors.New("agent is not registered on the server")
+
+const retryDelay = 2 * time.Second
+
+type Checker interface {
+	Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus
+}
+
+// Agent runs checks for one location on behalf of a remote server: it
+// registers, long-polls for leased links and posts the results back.
+type Agent struct {
+	server      string
+	location    string
+	token       string
+	checker     Checker
+	concurrency int
+	client      *http.Client
+}
+
+func New(server, location, token string, checker Checker, concurrency int) *Agent {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Agent{
+		server:      strings.TrimRight(server, "/"),
+		location:    location,
+		token:       token,
+		checker:     checker,
+		concurrency: concurrency,
+		client:      &http.Client{Timeout: time.Minute},
+	}
+}
+
+// Run works until ctx is cancelled. Server outages are retried; a server
+// restart that forgets the agent leads to a new registration.
+func (a *Agent) Run(ctx context.Context) error {
+	var agentID string
+	for ctx.Err() == nil {
+		if agentID == "" {
+			id, err := a.register(ctx)
+			if err != nil {
+				log.Printf("agent: register: %v", err)
+				sleep(ctx, retryDelay)
+				continue
+			}
+			agentID = id
+		}
+
+		jobs, err := a.poll(ctx, agentID)
+		if errors.Is(err, errUnknownAgent) {
+			agentID = ""
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("agent: poll: %v", err)
+				sleep(ctx, retryDelay)
+			}
+			continue
+		}
+		if len(jobs) == 0 {
+			continue
+		}
+
+		results := a.runJobs(ctx, jobs)
+		if err := a.ack(ctx, agentID, results); err != nil {
+			// Unacknowledged leases expire and the server hands them out again.
+			log.Printf("agent: send results: %v", err)
+			if errors.Is(err, errUnknownAgent) {
+				agentID = ""
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+func (a *Agent) runJobs(ctx context.Context, jobs []models.AgentJob) []models.AgentResult {
+	results := make([]models.AgentResult, len(jobs))
+	sem := make(chan struct{}, a.concurrency)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, job models.AgentJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := a.checker.Check(ctx, job.URL, job.Options)
+			results[i] = models.AgentResult{LeaseID: job.LeaseID, Result: result}
+			if job.Options.TrackText {
+				results[i].ContentText = result.ContentText
+			}
+		}(i, job)
+	}
+	wg.Wait()
+	return results
+}
+
+func (a *Agent) register(ctx context.Context) (string, error) {
+	var resp models.AgentRegisterResponse
+	status, err := a.post(ctx, "/agents/register", models.AgentRegisterRequest{Location: a.location}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusCreated {
+		return "", fmt.Errorf("unexpected status %d", status)
+	}
+	return resp.AgentID, nil
+}
+
+func (a *Agent) poll(ctx context.Context, agentID string) ([]models.AgentJob, error) {
+	var resp models.AgentPollResponse
+	path := fmt.Sprintf("/agents/%s/poll?max=%d", agentID, a.concurrency)
+	status, err := a.post(ctx, path, struct{}{}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return resp.Jobs, nil
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusNotFound:
+		return nil, errUnknownAgent
+	default:
+		return nil, fmt.Errorf("unexpected status %d", status)
+	}
+}
+
+func (a *Agent) ack(ctx context.Context, agentID string, results []models.AgentResult) error {
+	var resp models.AgentResultsResponse
+	status, err := a.post(ctx, "/agents/"+agentID+"/results", models.AgentResultsRequest{Results: results}, &resp)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusOK:
+		if resp.Accepted < len(results) {
+			log.Printf("agent: server accepted %d of %d results, the rest were reassigned", resp.Accepted, len(results))
+		}
+		return nil
+	case http.StatusNotFound:
+		return errUnknownAgent
+	default:
+		return fmt.Errorf("unexpected status %d", status)
+	}
+}
+
+func (a *Agent) post(ctx context.Context, path string, body, out interface{}) (int, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.server+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+a.token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
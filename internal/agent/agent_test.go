@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	api "github.com/whiterage/14-11-2025/internal/http"
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/internal/service"
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+type locationChecker string
+
+func (c locationChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	return models.LinkStatus{
+		URL:       url,
+		Status:    models.StatusAvailable,
+		Result:    models.ResultOK,
+		CheckTime: clock.Now(),
+		Details:   map[string]string{"checked_in": string(c)},
+	}
+}
+
+func TestAgents_CheckAssignedLocations(t *testing.T) {
+	t.Parallel()
+
+	hub := service.NewAgentHub("s3cret", time.Minute)
+	svc := service.NewService(repository.NewMemoryRepo(), locationChecker("server"), 5, service.WithAgents(hub))
+	pool := service.NewWorkerPool(svc, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	mux := http.NewServeMux()
+	api.NewHandlers(svc).Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	defer hub.Close()
+
+	for _, location := range []string{"dmz", "office"} {
+		go New(srv.URL, location, "s3cret", locationChecker(location), 2).Run(ctx)
+	}
+
+	id, err := svc.CreateTask(ctx, models.LinkRequest{
+		Links:     []string{"https://a.example", "https://b.example"},
+		Locations: []string{"dmz", "office", models.LocationLocal},
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !isDone(svc, id) {
+		if time.Now().After(deadline) {
+			task, _ := svc.GetTask(id)
+			t.Fatalf("task not finished: %+v", task.Results)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	task, _ := svc.GetTask(id)
+	if len(task.Results) != 6 {
+		t.Fatalf("expected a result per link and location, got %d", len(task.Results))
+	}
+	for _, res := range task.Results {
+		want := res.Location
+		if want == models.LocationLocal {
+			want = "server"
+		}
+		if res.Status != models.StatusAvailable || res.Details["checked_in"] != want {
+			t.Fatalf("result for %s@%s checked in %q: %+v", res.URL, res.Location, res.Details["checked_in"], res)
+		}
+	}
+}
+
+func TestAgents_RejectBadToken(t *testing.T) {
+	t.Parallel()
+
+	hub := service.NewAgentHub("s3cret", time.Minute)
+	svc := service.NewService(repository.NewMemoryRepo(), locationChecker("server"), 1, service.WithAgents(hub))
+	mux := http.NewServeMux()
+	api.NewHandlers(svc).Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	agent := New(srv.URL, "dmz", "wrong", locationChecker("dmz"), 1)
+	if _, err := agent.register(context.Background()); err == nil {
+		t.Fatalf("expected registration with a wrong token to fail")
+	}
+}
+
+func isDone(svc *service.Service, id int) bool {
+	task, err := svc.GetTask(id)
+	return err == nil && task.Status == models.StatusDone
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/service"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// AdminHandlers serve the operator API. Every request needs the admin
+// bearer token.
+type AdminHandlers struct {
+	pool       *service.WorkerPool
+	autoscaler *service.Autoscaler
+	token      string
+}
+
+// NewAdminHandlers creates the admin API; autoscaler may be nil.
+func NewAdminHandlers(pool *service.WorkerPool, autoscaler *service.Autoscaler, token string) *AdminHandlers {
+	return &AdminHandlers{pool: pool, autoscaler: autoscaler, token: token}
+}
+
+func (h *AdminHandlers) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/pool", h.poolAction)
+}
+
+// poolAction serves GET and PUT /admin/pool.
+func (h *AdminHandlers) poolAction(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req models.PoolUpdate
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		if status, err := h.update(req); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.status())
+}
+
+func (h *AdminHandlers) update(req models.PoolUpdate) (int, error) {
+	bounds := req.MinWorkers != 0 || req.MaxWorkers != 0
+	switch {
+	case req.Workers != 0 && bounds:
+		return http.StatusBadRequest, errors.New("set either workers or min_workers/max_workers")
+	case req.Workers != 0 && h.autoscaler != nil:
+		return http.StatusConflict, errors.New("autoscaling is enabled, change min_workers/max_workers instead")
+	case bounds && h.autoscaler == nil:
+		return http.StatusConflict, errors.New("autoscaling is disabled")
+	}
+
+	var err error
+	if bounds {
+		min, max := h.autoscaler.Bounds()
+		if req.MinWorkers != 0 {
+			min = req.MinWorkers
+		}
+		if req.MaxWorkers != 0 {
+			max = req.MaxWorkers
+		}
+		err = h.autoscaler.SetBounds(min, max)
+	} else {
+		err = h.pool.Resize(req.Workers)
+	}
+	switch {
+	case err == nil:
+		return 0, nil
+	case errors.Is(err, service.ErrInvalidPoolSize):
+		return http.StatusBadRequest, err
+	case errors.Is(err, service.ErrShuttingDown):
+		return http.StatusServiceUnavailable, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
+
+func (h *AdminHandlers) status() models.PoolStatus {
+	status := models.PoolStatus{
+		Workers:   h.pool.Size(),
+		Backlog:   h.pool.Backlog(),
+		LatencyMs: float64(h.pool.Latency()) / float64(time.Millisecond),
+	}
+	if h.autoscaler != nil {
+		min, max := h.autoscaler.Bounds()
+		status.Autoscale = &models.AutoscaleBounds{Min: min, Max: max}
+	}
+	return status
+}
+
+func (h *AdminHandlers) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && h.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/internal/service"
+)
+
+func TestAdminHandlers_Pool(t *testing.T) {
+	t.Parallel()
+
+	svc := service.NewService(repository.NewMemoryRepo(), nil, 1)
+	pool := service.NewWorkerPool(svc, 2)
+	mux := http.NewServeMux()
+	NewAdminHandlers(pool, nil, "admin-token").Register(mux)
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		want   int
+	}{
+		{name: "no token", method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodGet, token: "guess", want: http.StatusUnauthorized},
+		{name: "status", method: http.MethodGet, token: "admin-token", want: http.StatusOK},
+		{name: "resize", method: http.MethodPut, token: "admin-token", body: `{"workers": 6}`, want: http.StatusOK},
+		{name: "invalid size", method: http.MethodPut, token: "admin-token", body: `{"workers": -1}`, want: http.StatusBadRequest},
+		{name: "bounds without autoscaler", method: http.MethodPut, token: "admin-token", body: `{"max_workers": 8}`, want: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/admin/pool", strings.NewReader(tt.body))
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Fatalf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+	if pool.Size() != 6 {
+		t.Fatalf("pool size = %d, want 6", pool.Size())
+	}
+}
+
+func TestAdminHandlers_ResizeStoppedPool(t *testing.T) {
+	t.Parallel()
+
+	svc := service.NewService(repository.NewMemoryRepo(), nil, 1)
+	pool := service.NewWorkerPool(svc, 2)
+	pool.Stop()
+	mux := http.NewServeMux()
+	NewAdminHandlers(pool, nil, "admin-token").Register(mux)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/pool", strings.NewReader(`{"workers": 4}`))
+	req.Header.Set("Authorization", "Bearer admin-token")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503 (%s)", rec.Code, rec.Body.String())
+	}
+}
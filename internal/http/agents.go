@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/service"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+const (
+	agentPollWait = 25 * time.Second
+	maxAgentJobs  = 100
+)
+
+func (h *Handlers) registerAgent(w http.ResponseWriter, r *http.Request) {
+	hub, ok := h.agentHub(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AgentRegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := hub.Register(req.Location)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidLocations) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(models.AgentRegisterResponse{
+		AgentID:         id,
+		LeaseTTLSeconds: int(hub.LeaseTTL() / time.Second),
+	})
+}
+
+// agentAction serves /agents/{id}/poll and /agents/{id}/results.
+func (h *Handlers) agentAction(w http.ResponseWriter, r *http.Request) {
+	hub, ok := h.agentHub(w, r)
+	if !ok {
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/agents/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	agentID := parts[0]
+
+	switch parts[1] {
+	case "poll":
+		h.pollAgent(w, r, hub, agentID)
+	case "results":
+		h.ackAgent(w, r, hub, agentID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handlers) pollAgent(w http.ResponseWriter, r *http.Request, hub *service.AgentHub, agentID string) {
+	max, _ := strconv.Atoi(r.URL.Query().Get("max"))
+	if max <= 0 {
+		max = 1
+	}
+	max = min(max, maxAgentJobs)
+
+	ctx, cancel := context.WithTimeout(r.Context(), agentPollWait)
+	defer cancel()
+
+	jobs, err := hub.Poll(ctx, agentID, max)
+	if errors.Is(err, service.ErrUnknownAgent) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if len(jobs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(models.AgentPollResponse{Jobs: jobs})
+}
+
+func (h *Handlers) ackAgent(w http.ResponseWriter, r *http.Request, hub *service.AgentHub, agentID string) {
+	var req models.AgentResultsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	accepted, err := hub.Ack(agentID, req.Results)
+	if errors.Is(err, service.ErrUnknownAgent) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(models.AgentResultsResponse{Accepted: accepted})
+}
+
+// agentHub checks method and bearer token shared by every agent endpoint.
+func (h *Handlers) agentHub(w http.ResponseWriter, r *http.Request) (*service.AgentHub, bool) {
+	hub := h.svc.Agents()
+	if hub == nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !hub.Authorized(token) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return hub, true
+}
@@ -4,14 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/whiterage/14-11-2025/internal/service"
+	"github.com/whiterage/14-11-2025/pkg/clock"
 	"github.com/whiterage/14-11-2025/pkg/models"
 )
 
+// shutdownRetryAfter is the Retry-After hint, in seconds, sent while the
+// server is shutting down.
+const shutdownRetryAfter = 30
+
 type Handlers struct {
 	svc *service.Service
 }
@@ -22,8 +29,12 @@ func NewHandlers(svc *service.Service) *Handlers {
 
 func (h *Handlers) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/links", h.createLinks)
-	mux.HandleFunc("/links/", h.getLink)
+	mux.HandleFunc("/links/", h.link)
 	mux.HandleFunc("/links_list", h.generateReport)
+	mux.HandleFunc("/monitors", h.monitors)
+	mux.HandleFunc("/monitors/", h.monitor)
+	mux.HandleFunc("/agents/register", h.registerAgent)
+	mux.HandleFunc("/agents/", h.agentAction)
 }
 
 func (h *Handlers) createLinks(w http.ResponseWriter, r *http.Request) {
@@ -40,12 +51,29 @@ func (h *Handlers) createLinks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := h.svc.CreateTask(r.Context(), req.Links)
+	id, replayed, err := h.svc.CreateTaskIdempotent(r.Context(), r.Header.Get("Idempotency-Key"), req)
+	var full *service.QueueFullError
+	if errors.As(err, &full) {
+		writeQueueFull(w, full)
+		return
+	}
 	if err != nil {
 		status := http.StatusInternalServerError
 		switch {
-		case errors.Is(err, service.ErrEmptyLinks):
+		case errors.Is(err, service.ErrEmptyLinks), errors.Is(err, service.ErrInvalidAuth),
+			errors.Is(err, service.ErrInvalidCrawl), errors.Is(err, service.ErrInvalidLocations),
+			errors.Is(err, service.ErrInvalidTransaction), errors.Is(err, service.ErrInvalidPriority),
+			errors.Is(err, service.ErrInvalidDeadline), errors.Is(err, service.ErrInvalidCallback),
+			errors.Is(err, service.ErrInvalidIdempotencyKey):
 			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrInvalidSitemap), errors.Is(err, service.ErrSitemapTooLarge),
+			errors.Is(err, service.ErrIdempotencyMismatch):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, service.ErrIdempotencyInFlight):
+			status = http.StatusConflict
+		case errors.Is(err, service.ErrShuttingDown):
+			w.Header().Set("Retry-After", strconv.Itoa(shutdownRetryAfter))
+			status = http.StatusServiceUnavailable
 		case errors.Is(err, context.Canceled):
 			status = http.StatusRequestTimeout
 		}
@@ -59,24 +87,34 @@ func (h *Handlers) createLinks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := map[string]interface{}{
-		"links":     buildLinksMap(task.Results),
-		"links_num": task.ID,
-		"status":    task.Status,
+	status := http.StatusCreated
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+		status = http.StatusOK
 	}
-
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	_ = json.NewEncoder(w).Encode(resp)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(h.taskResponse(task))
 }
 
-func (h *Handlers) getLink(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
+// writeQueueFull rejects a task with 429 and tells the client when to come
+// back and how busy the queue is.
+func writeQueueFull(w http.ResponseWriter, full *service.QueueFullError) {
+	retry := int(math.Ceil(full.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":               full.Error(),
+		"queue_depth":         full.Depth,
+		"queue_capacity":      full.Capacity,
+		"retry_after_seconds": retry,
+	})
+}
 
-	idStr := strings.TrimPrefix(r.URL.Path, "/links/")
+// link serves GET and DELETE /links/{id} and POST /links/{id}/cancel.
+func (h *Handlers) link(w http.ResponseWriter, r *http.Request) {
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/links/"), "/")
 	if idStr == "" {
 		http.Error(w, "missing id", http.StatusBadRequest)
 		return
@@ -88,6 +126,21 @@ func (h *Handlers) getLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.getLink(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		h.deleteLink(w, r, id)
+	case action == "cancel" && r.Method == http.MethodPost:
+		h.cancelLink(w, r, id)
+	case action == "" || action == "cancel":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handlers) getLink(w http.ResponseWriter, r *http.Request, id int) {
 	task, err := h.svc.GetTask(id)
 	if errors.Is(err, service.ErrTaskNotFound) {
 		http.NotFound(w, r)
@@ -97,14 +150,40 @@ func (h *Handlers) getLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := map[string]interface{}{
-		"links":     buildLinksMap(task.Results),
-		"links_num": task.ID,
-		"status":    task.Status,
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.taskResponse(task))
+}
+
+func (h *Handlers) cancelLink(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.svc.CancelTask(id)
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, service.ErrTaskFinished):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
 	}
+	h.getLink(w, r, id)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+func (h *Handlers) deleteLink(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.svc.DeleteTask(r.Context(), id)
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, context.Canceled):
+		http.Error(w, err.Error(), http.StatusRequestTimeout)
+		return
+	case err != nil:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *Handlers) generateReport(w http.ResponseWriter, r *http.Request) {
@@ -142,10 +221,79 @@ func (h *Handlers) generateReport(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(data)
 }
 
+func (h *Handlers) taskResponse(task *models.Task) map[string]interface{} {
+	resp := map[string]interface{}{
+		"links":     buildLinksMap(task.Results),
+		"links_num": task.ID,
+		"status":    task.Status,
+		"results":   task.Results,
+	}
+	if task.Type != "" {
+		resp["type"] = task.Type
+	}
+	if task.Priority != "" {
+		resp["priority"] = task.Priority
+	}
+	if task.MonitorID != 0 {
+		resp["monitor_id"] = task.MonitorID
+	}
+	if task.Budget != nil {
+		resp["budget"] = budgetView(task)
+	}
+	if task.Callback != nil {
+		resp["callback"] = task.Callback
+	}
+	if task.Status == models.StatusPending {
+		if position := h.svc.QueuePosition(task.ID); position > 0 {
+			resp["queue_position"] = position
+		}
+	}
+	return resp
+}
+
+// budgetView reports the time used so far while the task is still running.
+func budgetView(task *models.Task) models.Budget {
+	budget := *task.Budget
+	if task.Status == models.StatusPending || task.Status == models.StatusProcessing {
+		budget.Used = float64(clock.Now().Sub(task.CreatedAt)) / float64(time.Millisecond)
+	}
+	return budget
+}
+
+// buildLinksMap reports one coarse status per requested link. A link checked
+// from several locations shows its worst status.
 func buildLinksMap(results []models.LinkStatus) map[string]string {
 	resp := make(map[string]string, len(results))
+	set := func(key, status string) {
+		if current, ok := resp[key]; ok && statusRank(current) >= statusRank(status) {
+			return
+		}
+		resp[key] = status
+	}
+
 	for _, res := range results {
-		resp[res.URL] = res.Status
+		status := res.Availability
+		if status == "" {
+			status = res.Status
+		}
+		if len(res.Inputs) == 0 {
+			set(res.URL, status)
+			continue
+		}
+		for _, input := range res.Inputs {
+			set(input, status)
+		}
 	}
 	return resp
 }
+
+func statusRank(status string) int {
+	switch status {
+	case models.StatusAvailable:
+		return 0
+	case models.StatusNotAvailable:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -1,9 +1,19 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/internal/service"
 	"github.com/whiterage/14-11-2025/pkg/models"
 )
 
@@ -11,15 +21,137 @@ func TestBuildLinksMap(t *testing.T) {
 	input := []models.LinkStatus{
 		{URL: "google.com", Status: models.StatusAvailable},
 		{URL: "example.com", Status: models.StatusNotAvailable},
+		{URL: "https://soft.example", Status: models.StatusSoft404, Availability: models.StatusNotAvailable},
+		{URL: "https://multi.example", Location: "dmz", Status: models.StatusAvailable, Availability: models.StatusAvailable},
+		{URL: "https://multi.example", Location: "office", Status: models.StatusNotAvailable, Availability: models.StatusNotAvailable},
+		{URL: "https://multi.example", Location: "local", Status: models.StatusPending},
+		{URL: "https://xn--d1abbgf6aiiy.xn--p1ai", Inputs: []string{"президент.рф", "https://ПРЕЗИДЕНТ.РФ/"}, Status: models.StatusAvailable},
 	}
 
 	got := buildLinksMap(input)
 	want := map[string]string{
-		"google.com":  models.StatusAvailable,
-		"example.com": models.StatusNotAvailable,
+		"google.com":            models.StatusAvailable,
+		"example.com":           models.StatusNotAvailable,
+		"https://soft.example":  models.StatusNotAvailable,
+		"https://multi.example": models.StatusNotAvailable,
+		"президент.рф":          models.StatusAvailable,
+		"https://ПРЕЗИДЕНТ.РФ/": models.StatusAvailable,
 	}
 
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("unexpected map: %+v", got)
 	}
 }
+
+func TestCreateLinks_QueueFull(t *testing.T) {
+	t.Parallel()
+
+	svc := service.NewService(repository.NewMemoryRepo(), nil, 1)
+	mux := http.NewServeMux()
+	NewHandlers(svc).Register(mux)
+
+	post := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/links", strings.NewReader(`{"links": ["example.com"]}`)))
+		return rec
+	}
+
+	if rec := post(); rec.Code != http.StatusCreated {
+		t.Fatalf("first task: status %d", rec.Code)
+	}
+	rec := post()
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(rec.Body.String(), `"queue_depth":1`) {
+		t.Fatalf("missing queue depth hint: %s", rec.Body.String())
+	}
+
+	svc.CloseQueue()
+	if rec := post(); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while shutting down, got %d", rec.Code)
+	}
+}
+
+func TestCreateLinks_IdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	open := func() *http.ServeMux {
+		repo, err := repository.NewPersistentRepo(path)
+		if err != nil {
+			t.Fatalf("open repo: %v", err)
+		}
+		mux := http.NewServeMux()
+		NewHandlers(service.NewService(repo, nil, 5)).Register(mux)
+		return mux
+	}
+	post := func(mux *http.ServeMux, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/links", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	mux := open()
+	first := post(mux, "order-42", `{"links": ["example.com"]}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first request: status %d", first.Code)
+	}
+	replay := post(mux, "order-42", `{ "links":["example.com"] }`)
+	if replay.Code != http.StatusOK || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replay, got %d %q", replay.Code, replay.Header().Get("Idempotent-Replayed"))
+	}
+	if !strings.Contains(replay.Body.String(), `"links_num":1`) {
+		t.Fatalf("replay returned another task: %s", replay.Body.String())
+	}
+	if rec := post(mux, "order-42", `{"links": ["example.org"]}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a different payload, got %d", rec.Code)
+	}
+	if rec := post(mux, "order-43", `{"links": ["example.com"]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("new key: status %d", rec.Code)
+	}
+
+	restarted := open()
+	if rec := post(restarted, "order-42", `{"links": ["example.com"]}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"links_num":1`) {
+		t.Fatalf("key lost after restart: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPollAgent_ClampsMax(t *testing.T) {
+	t.Parallel()
+
+	hub := service.NewAgentHub("agent-token", time.Minute)
+	svc := service.NewService(repository.NewMemoryRepo(), nil, 1, service.WithAgents(hub))
+	pool := service.NewWorkerPool(svc, 1)
+	pool.Start(context.Background())
+	defer pool.Stop()
+	mux := http.NewServeMux()
+	NewHandlers(svc).Register(mux)
+
+	links := make([]string, maxAgentJobs+50)
+	for i := range links {
+		links[i] = fmt.Sprintf("https://intranet.example/%d", i)
+	}
+	if _, err := svc.CreateTask(context.Background(), models.LinkRequest{Links: links, Locations: []string{"dmz"}}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	agentID, err := hub.Register("dmz")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/agents/"+agentID+"/poll?max=500", nil)
+	req.Header.Set("Authorization", "Bearer agent-token")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var resp models.AgentPollResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode poll response (status %d): %v", rec.Code, err)
+	}
+	if len(resp.Jobs) != maxAgentJobs {
+		t.Fatalf("poll with a large max returned %d jobs, want %d", len(resp.Jobs), maxAgentJobs)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/service"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+const monitorRunsShown = 20
+
+type monitorRun struct {
+	ID        int       `json:"links_num"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type monitorView struct {
+	models.Monitor
+	Runs []monitorRun `json:"runs"`
+}
+
+// monitors serves GET and POST /monitors.
+func (h *Handlers) monitors(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(h.svc.ListMonitors())
+	case http.MethodPost:
+		req, ok := decodeMonitor(w, r)
+		if !ok {
+			return
+		}
+		monitor, err := h.svc.CreateMonitor(req)
+		if err != nil {
+			writeMonitorError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(monitor)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// monitor serves GET, PUT and DELETE /monitors/{id}.
+func (h *Handlers) monitor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/monitors/"))
+	if err != nil {
+		http.Error(w, "invalid monitor id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		monitor, err := h.svc.GetMonitor(id)
+		if err != nil {
+			writeMonitorError(w, r, err)
+			return
+		}
+		runs, _ := h.svc.MonitorRuns(id)
+		view := monitorView{Monitor: monitor, Runs: make([]monitorRun, 0, min(len(runs), monitorRunsShown))}
+		for _, task := range runs[:min(len(runs), monitorRunsShown)] {
+			view.Runs = append(view.Runs, monitorRun{ID: task.ID, Status: task.Status, CreatedAt: task.CreatedAt})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(view)
+	case http.MethodPut:
+		req, ok := decodeMonitor(w, r)
+		if !ok {
+			return
+		}
+		monitor, err := h.svc.UpdateMonitor(id, req)
+		if err != nil {
+			writeMonitorError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(monitor)
+	case http.MethodDelete:
+		if err := h.svc.DeleteMonitor(id); err != nil {
+			writeMonitorError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func decodeMonitor(w http.ResponseWriter, r *http.Request) (models.MonitorRequest, bool) {
+	var req models.MonitorRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+func writeMonitorError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrMonitorNotFound):
+		http.NotFound(w, r)
+	case errors.Is(err, service.ErrInvalidMonitor), errors.Is(err, service.ErrEmptyLinks),
+		errors.Is(err, service.ErrInvalidAuth), errors.Is(err, service.ErrInvalidCrawl),
+		errors.Is(err, service.ErrInvalidTransaction), errors.Is(err, service.ErrInvalidPriority),
+		errors.Is(err, service.ErrInvalidDeadline), errors.Is(err, service.ErrInvalidCallback):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
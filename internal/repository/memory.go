@@ -6,20 +6,28 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/whiterage/14-11-2025/pkg/models"
 )
 
 type MemoryRepo struct {
 	tasks       map[int]*models.Task
+	snapshots   map[string]*models.ContentSnapshot
+	monitors    map[int]*models.Monitor
+	idempotency map[string]*models.IdempotencyKey
 	mu          sync.RWMutex
 	storagePath string
 }
 
 func NewMemoryRepo() *MemoryRepo {
 	return &MemoryRepo{
-		tasks: make(map[int]*models.Task),
+		tasks:       make(map[int]*models.Task),
+		snapshots:   make(map[string]*models.ContentSnapshot),
+		monitors:    make(map[int]*models.Monitor),
+		idempotency: make(map[string]*models.IdempotencyKey),
 	}
 }
 
@@ -30,6 +38,9 @@ func NewPersistentRepo(path string) (*MemoryRepo, error) {
 
 	repo := &MemoryRepo{
 		tasks:       make(map[int]*models.Task),
+		snapshots:   make(map[string]*models.ContentSnapshot),
+		monitors:    make(map[int]*models.Monitor),
+		idempotency: make(map[string]*models.IdempotencyKey),
 		storagePath: path,
 	}
 
@@ -40,10 +51,66 @@ func NewPersistentRepo(path string) (*MemoryRepo, error) {
 	return repo, nil
 }
 
+// Save stores a copy of the task. The repository never hands out the
+// stored copies either, so tasks being checked can be changed while others
+// read or persist them.
 func (r *MemoryRepo) Save(task *models.Task) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.tasks[task.ID] = task
+	r.tasks[task.ID] = task.Clone()
+	r.persistLocked()
+}
+
+// Replace stores the task only if it still exists, so a slow writer does
+// not bring back a deleted task.
+func (r *MemoryRepo) Replace(task *models.Task) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[task.ID]; !ok {
+		return false
+	}
+	r.tasks[task.ID] = task.Clone()
+	r.persistLocked()
+	return true
+}
+
+// SaveWithKey stores a new task together with the idempotency key that
+// created it, in a single write.
+func (r *MemoryRepo) SaveWithKey(task *models.Task, key *models.IdempotencyKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks[task.ID] = task.Clone()
+	r.idempotency[key.Key] = key
+	r.persistLocked()
+}
+
+func (r *MemoryRepo) IdempotencyKey(key string) (*models.IdempotencyKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.idempotency[key]
+	return entry, ok
+}
+
+// ExpireIdempotencyKeys drops keys created before the given time.
+func (r *MemoryRepo) ExpireIdempotencyKeys(before time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expired := 0
+	for key, entry := range r.idempotency {
+		if entry.CreatedAt.Before(before) {
+			delete(r.idempotency, key)
+			expired++
+		}
+	}
+	if expired > 0 {
+		r.persistLocked()
+	}
+}
+
+func (r *MemoryRepo) Delete(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tasks, id)
 	r.persistLocked()
 }
 
@@ -51,7 +118,21 @@ func (r *MemoryRepo) Get(id int) (*models.Task, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	task, ok := r.tasks[id]
-	return task, ok
+	if !ok {
+		return nil, false
+	}
+	return task.Clone(), true
+}
+
+// Status returns the stored status of a task without copying it.
+func (r *MemoryRepo) Status(id int) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	task, ok := r.tasks[id]
+	if !ok {
+		return "", false
+	}
+	return task.Status, true
 }
 
 func (r *MemoryRepo) List(ids []int) []*models.Task {
@@ -60,7 +141,7 @@ func (r *MemoryRepo) List(ids []int) []*models.Task {
 	tasks := make([]*models.Task, 0, len(ids))
 	for _, id := range ids {
 		if task, ok := r.tasks[id]; ok {
-			tasks = append(tasks, task)
+			tasks = append(tasks, task.Clone())
 		}
 	}
 	return tasks
@@ -73,12 +154,108 @@ func (r *MemoryRepo) PendingTasks() []*models.Task {
 	var tasks []*models.Task
 	for _, task := range r.tasks {
 		if task.Status == models.StatusPending || task.Status == models.StatusProcessing {
-			tasks = append(tasks, task)
+			tasks = append(tasks, task.Clone())
+		}
+	}
+	return tasks
+}
+
+// PendingCallbacks returns tasks whose webhook still has to be delivered.
+func (r *MemoryRepo) PendingCallbacks() []*models.Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*models.Task
+	for _, task := range r.tasks {
+		if task.Callback != nil && task.Callback.Status == models.CallbackPending {
+			tasks = append(tasks, task.Clone())
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+func (r *MemoryRepo) Snapshot(url string) (models.ContentSnapshot, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snap, ok := r.snapshots[url]
+	if !ok {
+		return models.ContentSnapshot{}, false
+	}
+	return *snap, true
+}
+
+// SaveSnapshot keeps the snapshot in memory; it reaches the storage file
+// with the next write, at the latest when the task that took it is saved.
+// Snapshots are saved for every checked page, so writing the whole file
+// each time would stall the checks.
+func (r *MemoryRepo) SaveSnapshot(url string, snap models.ContentSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots[url] = &snap
+}
+
+func (r *MemoryRepo) SaveMonitor(monitor *models.Monitor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.monitors[monitor.ID] = monitor.Clone()
+	r.persistLocked()
+}
+
+func (r *MemoryRepo) DeleteMonitor(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.monitors, id)
+	r.persistLocked()
+}
+
+// Monitors returns every monitor ordered by id.
+func (r *MemoryRepo) Monitors() []*models.Monitor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	monitors := make([]*models.Monitor, 0, len(r.monitors))
+	for _, monitor := range r.monitors {
+		monitors = append(monitors, monitor.Clone())
+	}
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i].ID < monitors[j].ID })
+	return monitors
+}
+
+// MonitorRuns returns the tasks started by a monitor, newest first.
+func (r *MemoryRepo) MonitorRuns(monitorID int) []*models.Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*models.Task
+	for _, task := range r.tasks {
+		if task.MonitorID == monitorID {
+			tasks = append(tasks, task.Clone())
 		}
 	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID > tasks[j].ID })
 	return tasks
 }
 
+// MaxMonitorID also looks at tasks, so ids of deleted monitors are not
+// reused and their runs stay apart.
+func (r *MemoryRepo) MaxMonitorID() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	max := 0
+	for id := range r.monitors {
+		if id > max {
+			max = id
+		}
+	}
+	for _, task := range r.tasks {
+		if task.MonitorID > max {
+			max = task.MonitorID
+		}
+	}
+	return max
+}
+
 func (r *MemoryRepo) MaxID() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -115,8 +292,20 @@ func (r *MemoryRepo) load() error {
 	}
 
 	for _, task := range state.Tasks {
+		if state.Version < storageVersion {
+			migrateResults(task)
+		}
 		r.tasks[task.ID] = task
 	}
+	for url, snap := range state.Snapshots {
+		r.snapshots[url] = snap
+	}
+	for _, monitor := range state.Monitors {
+		r.monitors[monitor.ID] = monitor
+	}
+	for _, entry := range state.IdempotencyKeys {
+		r.idempotency[entry.Key] = entry
+	}
 
 	return nil
 }
@@ -127,11 +316,19 @@ func (r *MemoryRepo) persistLocked() {
 	}
 
 	state := storageState{
-		Tasks: make([]*models.Task, 0, len(r.tasks)),
+		Version:   storageVersion,
+		Tasks:     make([]*models.Task, 0, len(r.tasks)),
+		Snapshots: r.snapshots,
 	}
 	for _, task := range r.tasks {
 		state.Tasks = append(state.Tasks, task)
 	}
+	for _, monitor := range r.monitors {
+		state.Monitors = append(state.Monitors, monitor)
+	}
+	for _, entry := range r.idempotency {
+		state.IdempotencyKeys = append(state.IdempotencyKeys, entry)
+	}
 
 	tmp := r.storagePath + ".tmp"
 	file, err := os.Create(tmp)
@@ -159,6 +356,48 @@ func (r *MemoryRepo) persistLocked() {
 	}
 }
 
+// storageVersion 1 added result classes and availability to link results;
+// 2 gave links skipped by robots.txt their own class and availability.
+const storageVersion = 2
+
 type storageState struct {
-	Tasks []*models.Task `json:"tasks"`
+	Version   int                                `json:"version"`
+	Tasks     []*models.Task                     `json:"tasks"`
+	Snapshots map[string]*models.ContentSnapshot `json:"snapshots,omitempty"`
+	Monitors  []*models.Monitor                  `json:"monitors,omitempty"`
+
+	IdempotencyKeys []*models.IdempotencyKey `json:"idempotency_keys,omitempty"`
+}
+
+// migrateResults fills in availability and result classes for results
+// stored before they existed. The original failure reason is unknown, so
+// failed links become "unclassified".
+func migrateResults(task *models.Task) {
+	for i := range task.Results {
+		res := &task.Results[i]
+		if res.Status == models.StatusSkippedRobots {
+			res.Availability = models.StatusSkippedRobots
+			res.Result = models.ResultRobotsDisallowed
+			continue
+		}
+		if res.Availability == "" {
+			res.Availability = models.AvailabilityOf(res.Status)
+		}
+		if res.Result != "" {
+			continue
+		}
+		switch res.Status {
+		case models.StatusPending, models.StatusProcessing:
+		case models.StatusAvailable:
+			res.Result = models.ResultOK
+		case models.StatusBrokenResources:
+			res.Result = models.ResultDegraded
+		case models.StatusSoft404:
+			res.Result = models.ResultClientError
+		case models.StatusUnsupportedScheme:
+			res.Result = models.ResultInvalidURL
+		default:
+			res.Result = models.ResultUnclassified
+		}
+	}
 }
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -59,3 +61,73 @@ func TestMemoryRepo_PendingTasks(t *testing.T) {
 		t.Fatalf("expected 2 pending tasks, got %d", len(pending))
 	}
 }
+
+func TestMemoryRepo_StoresCopies(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMemoryRepo()
+	task := &models.Task{ID: 1, Status: models.StatusProcessing, Results: []models.LinkStatus{{URL: "https://example.com", Status: models.StatusPending}}}
+	repo.Save(task)
+	task.Status = models.StatusDone
+	task.Results[0].Status = models.StatusAvailable
+
+	got, _ := repo.Get(1)
+	if got.Status != models.StatusProcessing || got.Results[0].Status != models.StatusPending {
+		t.Fatalf("stored task changed with the saved one: %+v", got)
+	}
+	got.Results[0].Status = models.StatusNotAvailable
+	if again, _ := repo.Get(1); again.Results[0].Status != models.StatusPending {
+		t.Fatalf("stored task changed with a returned one: %+v", again)
+	}
+
+	repo.Delete(1)
+	if repo.Replace(task) {
+		t.Fatalf("Replace brought back a deleted task")
+	}
+}
+
+func TestPersistentRepo_MigratesLegacyResults(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	legacy := `{"tasks": [{"links_num": 3, "status": "done", "results": [
+  {"url": "https://ok.example", "status": "available"},
+  {"url": "https://down.example", "status": "not_available"},
+  {"url": "https://later.example", "status": "pending"},
+  {"url": "https://private.example", "status": "skipped_robots", "availability": "not_available"}
+]}], "version": 1}`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatalf("write legacy storage: %v", err)
+	}
+
+	repo, err := NewPersistentRepo(path)
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	task, _ := repo.Get(3)
+
+	want := []struct {
+		availability string
+		result       models.ResultClass
+	}{
+		{models.StatusAvailable, models.ResultOK},
+		{models.StatusNotAvailable, models.ResultUnclassified},
+		{"", ""},
+		{models.StatusSkippedRobots, models.ResultRobotsDisallowed},
+	}
+	for i, w := range want {
+		res := task.Results[i]
+		if res.Availability != w.availability || res.Result != w.result {
+			t.Fatalf("result %d migrated to %q/%q, want %q/%q", i, res.Availability, res.Result, w.availability, w.result)
+		}
+	}
+
+	repo.Save(task)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read storage: %v", err)
+	}
+	if !strings.Contains(string(data), `"version": 2`) {
+		t.Fatalf("storage version not written:\n%s", data)
+	}
+}
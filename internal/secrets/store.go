@@ -0,0 +1,200 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	ErrNoKey        = errors.New("secrets key is required")
+	ErrCorruptStore = errors.New("secrets file is corrupt or key is wrong")
+)
+
+// fileMagic starts store files whose key is derived with scrypt from the
+// passphrase and the salt that follows the magic. Older files have no
+// header and an unsalted SHA-256 key; they are rewritten on the next change.
+const (
+	fileMagic = "LCS1"
+	saltSize  = 16
+)
+
+type Store struct {
+	path   string
+	salt   []byte
+	key    []byte
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func Open(path, passphrase string) (*Store, error) {
+	if passphrase == "" {
+		return nil, ErrNoKey
+	}
+
+	s := &Store{
+		path:   path,
+		values: make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, s.newKey(passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var key, sealed []byte
+	if rest, ok := bytes.CutPrefix(data, []byte(fileMagic)); ok {
+		if len(rest) < saltSize {
+			return nil, ErrCorruptStore
+		}
+		s.salt = rest[:saltSize]
+		if s.key, err = deriveKey(passphrase, s.salt); err != nil {
+			return nil, err
+		}
+		key, sealed = s.key, rest[saltSize:]
+	} else {
+		legacy := sha256.Sum256([]byte(passphrase))
+		key, sealed = legacy[:], data
+	}
+
+	plain, err := decrypt(key, sealed)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(plain, &s.values); err != nil {
+		return nil, ErrCorruptStore
+	}
+
+	if s.key == nil {
+		return s, s.newKey(passphrase)
+	}
+	return s, nil
+}
+
+// newKey picks a fresh salt for the next write of the store.
+func (s *Store) newKey(passphrase string) error {
+	s.salt = make([]byte, saltSize)
+	if _, err := rand.Read(s.salt); err != nil {
+		return err
+	}
+	key, err := deriveKey(passphrase, s.salt)
+	if err != nil {
+		return err
+	}
+	s.key = key
+	return nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+func (s *Store) Lookup(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[name]
+	return value, ok
+}
+
+func (s *Store) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Store) Set(name, value string) error {
+	if name == "" {
+		return errors.New("secret name is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[name] = value
+	return s.persistLocked()
+}
+
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, name)
+	return s.persistLocked()
+}
+
+func (s *Store) persistLocked() error {
+	plain, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := encrypt(s.key, plain)
+	if err != nil {
+		return err
+	}
+	data := append(append([]byte(fileMagic), s.salt...), sealed...)
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func encrypt(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func decrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrCorruptStore
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrCorruptStore
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
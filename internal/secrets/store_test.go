@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	store, err := Open(path, "passphrase")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	if err := store.Set("staging_token", "s3cr3t-value"); err != nil {
+		t.Fatalf("set secret: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if bytes.Contains(raw, []byte("s3cr3t-value")) {
+		t.Fatalf("secret value stored in plain text")
+	}
+
+	reopened, err := Open(path, "passphrase")
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	value, ok := reopened.Lookup("staging_token")
+	if !ok || value != "s3cr3t-value" {
+		t.Fatalf("unexpected lookup result: %q %v", value, ok)
+	}
+}
+
+func TestStore_WrongKey(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	store, err := Open(path, "right")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	if err := store.Set("name", "value"); err != nil {
+		t.Fatalf("set secret: %v", err)
+	}
+
+	if _, err := Open(path, "wrong"); !errors.Is(err, ErrCorruptStore) {
+		t.Fatalf("expected ErrCorruptStore, got %v", err)
+	}
+}
+
+func TestStore_SaltsKeysAndUpgradesLegacyFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	legacyKey := sha256.Sum256([]byte("passphrase"))
+	sealed, err := encrypt(legacyKey[:], []byte(`{"old_token":"v1"}`))
+	if err != nil {
+		t.Fatalf("encrypt legacy store: %v", err)
+	}
+	path := filepath.Join(dir, "legacy.enc")
+	if err := os.WriteFile(path, sealed, 0o600); err != nil {
+		t.Fatalf("write legacy store: %v", err)
+	}
+
+	store, err := Open(path, "passphrase")
+	if err != nil {
+		t.Fatalf("open legacy store: %v", err)
+	}
+	if value, ok := store.Lookup("old_token"); !ok || value != "v1" {
+		t.Fatalf("legacy secret lost: %q %v", value, ok)
+	}
+	if err := store.Set("new_token", "v2"); err != nil {
+		t.Fatalf("set secret: %v", err)
+	}
+
+	other := filepath.Join(dir, "other.enc")
+	second, err := Open(other, "passphrase")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	if err := second.Set("new_token", "v2"); err != nil {
+		t.Fatalf("set secret: %v", err)
+	}
+
+	upgraded, _ := os.ReadFile(path)
+	fresh, _ := os.ReadFile(other)
+	if !bytes.HasPrefix(upgraded, []byte(fileMagic)) {
+		t.Fatalf("legacy store not rewritten with a salted key")
+	}
+	salt := func(data []byte) []byte { return data[len(fileMagic) : len(fileMagic)+saltSize] }
+	if bytes.Equal(salt(upgraded), salt(fresh)) {
+		t.Fatalf("stores with the same passphrase share a salt")
+	}
+
+	reopened, err := Open(path, "passphrase")
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	if value, _ := reopened.Lookup("old_token"); value != "v1" {
+		t.Fatalf("secret lost after upgrade: %q", value)
+	}
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var (
+	ErrInvalidLocations = errors.New("invalid locations")
+	ErrUnknownAgent     = errors.New("unknown agent")
+)
+
+const (
+	defaultLeaseTTL = time.Minute
+	// agentTimeoutLeases is how many lease TTLs an agent may stay silent
+	// before it is forgotten, and how long a link waits for an agent of its
+	// location before it fails with no_agent.
+	agentTimeoutLeases = 5
+)
+
+func WithAgents(hub *AgentHub) Option {
+	return func(s *Service) {
+		s.agents = hub
+	}
+}
+
+// agentJob is a remote result of a run. The task ID, URL, location and
+// options are copied so the hub never reads the task, which belongs to the
+// run.
+type agentJob struct {
+	run      *taskRun
+	taskID   int
+	index    int
+	url      string
+	location string
+	opts     models.CheckOptions
+	queued   time.Time
+}
+
+type agentResult struct {
+	job    agentJob
+	result models.LinkStatus
+}
+
+type agentLease struct {
+	job     agentJob
+	agentID string
+	expires time.Time
+}
+
+type agentInfo struct {
+	location string
+	lastSeen time.Time
+}
+
+// AgentHub hands links with a remote location to agents running in that
+// location. Agents lease jobs by long polling and acknowledge them with
+// results; leases that are not acknowledged in time go back to the queue so
+// work of a lost agent is picked up by another one. Results are recorded by
+// the task's run, like local checks.
+type AgentHub struct {
+	token    string
+	leaseTTL time.Duration
+
+	mu      sync.Mutex
+	agents  map[string]*agentInfo
+	pending map[string][]agentJob
+	leases  map[string]*agentLease
+	wake    map[string]chan struct{}
+	armed   bool
+	closed  chan struct{}
+	closeW  sync.Once
+}
+
+func NewAgentHub(token string, leaseTTL time.Duration) *AgentHub {
+	if leaseTTL <= 0 {
+		leaseTTL = defaultLeaseTTL
+	}
+	return &AgentHub{
+		token:    token,
+		leaseTTL: leaseTTL,
+		agents:   make(map[string]*agentInfo),
+		pending:  make(map[string][]agentJob),
+		leases:   make(map[string]*agentLease),
+		wake:     make(map[string]chan struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (h *AgentHub) Authorized(token string) bool {
+	return h.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func (h *AgentHub) LeaseTTL() time.Duration {
+	return h.leaseTTL
+}
+
+func (h *AgentHub) Register(location string) (string, error) {
+	location = strings.TrimSpace(location)
+	if location == "" || location == models.LocationLocal {
+		return "", fmt.Errorf("%w: agent location must be a non-empty label other than %q", ErrInvalidLocations, models.LocationLocal)
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.agents[id] = &agentInfo{location: location, lastSeen: time.Now()}
+	return id, nil
+}
+
+// Poll leases up to max jobs for the agent's location, waiting until work
+// arrives, ctx is done or the hub is closed.
+func (h *AgentHub) Poll(ctx context.Context, agentID string, max int) ([]models.AgentJob, error) {
+	if max <= 0 {
+		max = 1
+	}
+
+	for {
+		now := time.Now()
+
+		h.mu.Lock()
+		agent, ok := h.agents[agentID]
+		if !ok {
+			h.mu.Unlock()
+			return nil, ErrUnknownAgent
+		}
+		agent.lastSeen = now
+		failed := h.reapLocked(now)
+
+		jobs := h.leaseLocked(agentID, agent.location, max, now)
+		wake := h.wakeLocked(agent.location)
+		wait := h.nextExpiryLocked(agent.location, now)
+		h.mu.Unlock()
+		record(failed)
+
+		if len(jobs) > 0 {
+			return jobs, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil
+		case <-h.closed:
+			timer.Stop()
+			return nil, nil
+		case <-wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// Ack stores results for leases held by the agent and returns how many were
+// accepted. Results for expired or reassigned leases are dropped.
+func (h *AgentHub) Ack(agentID string, results []models.AgentResult) (int, error) {
+	now := time.Now()
+
+	h.mu.Lock()
+	agent, ok := h.agents[agentID]
+	if !ok {
+		h.mu.Unlock()
+		return 0, ErrUnknownAgent
+	}
+	agent.lastSeen = now
+
+	var accepted []agentResult
+	for _, res := range results {
+		lease, ok := h.leases[res.LeaseID]
+		if !ok || lease.agentID != agentID || now.After(lease.expires) {
+			continue
+		}
+		delete(h.leases, res.LeaseID)
+
+		result := res.Result
+		result.ContentText = res.ContentText
+		accepted = append(accepted, agentResult{job: lease.job, result: result})
+	}
+	failed := h.reapLocked(now)
+	h.mu.Unlock()
+
+	record(accepted)
+	record(failed)
+	return len(accepted), nil
+}
+
+// Close wakes every waiting poll so the HTTP server can shut down.
+func (h *AgentHub) Close() {
+	h.closeW.Do(func() {
+		close(h.closed)
+	})
+}
+
+// dispatch queues remote results of a run. Each result is recorded by the
+// run once an agent acknowledges it or gives up with no_agent.
+func (h *AgentHub) dispatch(run *taskRun, indices []int) {
+	now := time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	// A run cancelled while it was starting has nothing to dispatch.
+	if run.done {
+		return
+	}
+
+	task := run.task
+	for _, i := range indices {
+		res := &task.Results[i]
+		job := agentJob{
+			run:      run,
+			taskID:   task.ID,
+			index:    i,
+			url:      res.URL,
+			location: res.Location,
+			opts:     task.Options,
+			queued:   now,
+		}
+		h.pending[job.location] = append(h.pending[job.location], job)
+		h.signalLocked(job.location)
+	}
+	h.armLocked()
+}
+
+// cancel drops queued and leased jobs of a task; late results for its
+// leases are ignored.
+func (h *AgentHub) cancel(taskID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for location, queue := range h.pending {
+		kept := queue[:0]
+		for _, job := range queue {
+			if job.taskID != taskID {
+				kept = append(kept, job)
+			}
+		}
+		h.pending[location] = kept
+	}
+	for id, lease := range h.leases {
+		if lease.job.taskID == taskID {
+			delete(h.leases, id)
+		}
+	}
+}
+
+// armLocked makes sure queued jobs are reaped even when no agent polls.
+func (h *AgentHub) armLocked() {
+	if h.armed {
+		return
+	}
+	h.armed = true
+	time.AfterFunc(h.leaseTTL, func() {
+		h.mu.Lock()
+		h.armed = false
+		failed := h.reapLocked(time.Now())
+		for _, queue := range h.pending {
+			if len(queue) > 0 {
+				h.armLocked()
+				break
+			}
+		}
+		h.mu.Unlock()
+		record(failed)
+	})
+}
+
+func (h *AgentHub) leaseLocked(agentID, location string, max int, now time.Time) []models.AgentJob {
+	queue := h.pending[location]
+	if len(queue) == 0 {
+		return nil
+	}
+	if len(queue) < max {
+		max = len(queue)
+	}
+
+	jobs := make([]models.AgentJob, 0, max)
+	for _, job := range queue[:max] {
+		id, err := randomID()
+		if err != nil {
+			break
+		}
+		h.leases[id] = &agentLease{job: job, agentID: agentID, expires: now.Add(h.leaseTTL)}
+		jobs = append(jobs, models.AgentJob{
+			LeaseID: id,
+			URL:     job.url,
+			Options: job.opts,
+		})
+	}
+	h.pending[location] = queue[len(jobs):]
+	return jobs
+}
+
+// reapLocked requeues expired leases, forgets agents that stopped polling
+// and returns the jobs that failed because no agent of their location is
+// left. Those wait agentTimeoutLeases lease TTLs first, so agents can
+// restart. Callers record the failures once the lock is released.
+func (h *AgentHub) reapLocked(now time.Time) []agentResult {
+	for id, agent := range h.agents {
+		if now.Sub(agent.lastSeen) > agentTimeoutLeases*h.leaseTTL {
+			delete(h.agents, id)
+		}
+	}
+	for id, lease := range h.leases {
+		if now.After(lease.expires) {
+			delete(h.leases, id)
+			job := lease.job
+			job.queued = now
+			h.pending[job.location] = append([]agentJob{job}, h.pending[job.location]...)
+			h.signalLocked(job.location)
+		}
+	}
+
+	var failed []agentResult
+	for location, queue := range h.pending {
+		if len(queue) == 0 || h.hasAgentLocked(location) {
+			continue
+		}
+		kept := queue[:0]
+		for _, job := range queue {
+			if now.Sub(job.queued) < agentTimeoutLeases*h.leaseTTL {
+				kept = append(kept, job)
+				continue
+			}
+			failed = append(failed, agentResult{job: job, result: models.LinkStatus{
+				Status:    models.StatusNotAvailable,
+				Result:    models.ResultNoAgent,
+				Error:     fmt.Sprintf("no agent in location %q picked up the link", location),
+				CheckTime: clock.Now(),
+			}})
+		}
+		h.pending[location] = kept
+	}
+	if len(h.leases) > 0 {
+		h.armLocked()
+	}
+	return failed
+}
+
+func (h *AgentHub) hasAgentLocked(location string) bool {
+	for _, agent := range h.agents {
+		if agent.location == location {
+			return true
+		}
+	}
+	return false
+}
+
+// record hands remote results to their runs.
+func record(results []agentResult) {
+	for _, r := range results {
+		r.job.run.pool.recordRemote(r.job.run, r.job.index, r.result)
+	}
+}
+
+func (h *AgentHub) nextExpiryLocked(location string, now time.Time) time.Duration {
+	wait := h.leaseTTL
+	for _, lease := range h.leases {
+		if lease.job.location != location {
+			continue
+		}
+		if until := lease.expires.Sub(now) + time.Millisecond; until < wait {
+			wait = until
+		}
+	}
+	return wait
+}
+
+func (h *AgentHub) wakeLocked(location string) chan struct{} {
+	ch, ok := h.wake[location]
+	if !ok {
+		ch = make(chan struct{})
+		h.wake[location] = ch
+	}
+	return ch
+}
+
+func (h *AgentHub) signalLocked(location string) {
+	if ch, ok := h.wake[location]; ok {
+		close(ch)
+		delete(h.wake, location)
+	}
+}
+
+func (s *Service) Agents() *AgentHub {
+	return s.agents
+}
+
+func normalizeLocations(locations []string, remoteEnabled bool) ([]string, error) {
+	seen := make(map[string]bool, len(locations))
+	var result []string
+	for _, location := range locations {
+		location = strings.TrimSpace(location)
+		if location == "" {
+			return nil, fmt.Errorf("%w: empty location", ErrInvalidLocations)
+		}
+		if location != models.LocationLocal && !remoteEnabled {
+			return nil, fmt.Errorf("%w: remote agents are not configured", ErrInvalidLocations)
+		}
+		if !seen[location] {
+			seen[location] = true
+			result = append(result, location)
+		}
+	}
+	return result, nil
+}
+
+// expandLocations repeats every result once per requested location.
+func expandLocations(results []models.LinkStatus, locations []string) []models.LinkStatus {
+	expanded := make([]models.LinkStatus, 0, len(results)*len(locations))
+	for _, res := range results {
+		for _, location := range locations {
+			copyRes := res
+			copyRes.Location = location
+			expanded = append(expanded, copyRes)
+		}
+	}
+	return expanded
+}
+
+func isRemote(res models.LinkStatus) bool {
+	return res.Location != "" && res.Location != models.LocationLocal
+}
+
+func randomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func markNoAgents(res *models.LinkStatus) {
+	res.Status = models.StatusNotAvailable
+	res.Availability = models.StatusNotAvailable
+	res.Result = models.ResultNoAgent
+	res.Error = "remote agents are not configured"
+	res.CheckTime = clock.Now()
+}
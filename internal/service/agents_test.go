@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestAgentHub_ReassignsExpiredLeases(t *testing.T) {
+	t.Parallel()
+
+	hub := NewAgentHub("token", 50*time.Millisecond)
+	svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 1, WithAgents(hub))
+	id := startRemoteTask(t, svc, models.LinkRequest{Links: []string{"https://intranet.example"}, Locations: []string{"office"}})
+
+	lost, _ := hub.Register("office")
+	jobs, err := hub.Poll(context.Background(), lost, 10)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("first poll: %v %+v", err, jobs)
+	}
+
+	healthy, _ := hub.Register("office")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	retry, err := hub.Poll(ctx, healthy, 10)
+	if err != nil || len(retry) != 1 || retry[0].URL != "https://intranet.example" {
+		t.Fatalf("expired lease was not reassigned: %v %+v", err, retry)
+	}
+
+	late := []models.AgentResult{{LeaseID: jobs[0].LeaseID, Result: models.LinkStatus{Status: models.StatusNotAvailable}}}
+	if accepted, _ := hub.Ack(lost, late); accepted != 0 {
+		t.Fatalf("late ack of an expired lease was accepted")
+	}
+
+	ok := []models.AgentResult{{LeaseID: retry[0].LeaseID, Result: models.LinkStatus{Status: models.StatusAvailable}}}
+	if accepted, _ := hub.Ack(healthy, ok); accepted != 1 {
+		t.Fatalf("ack was not accepted")
+	}
+
+	got, _ := svc.GetTask(id)
+	if got.Status != models.StatusDone || got.Results[0].Status != models.StatusAvailable || got.Results[0].Location != "office" {
+		t.Fatalf("unexpected task after ack: %+v", got)
+	}
+
+	if _, err := hub.Ack("nobody", ok); !errors.Is(err, ErrUnknownAgent) {
+		t.Fatalf("expected ErrUnknownAgent, got %v", err)
+	}
+}
+
+func TestCreateTask_Locations(t *testing.T) {
+	t.Parallel()
+
+	plain := NewService(repository.NewMemoryRepo(), fakeSite{}, 1)
+	_, err := plain.CreateTask(context.Background(), models.LinkRequest{Links: []string{"example.com"}, Locations: []string{"dmz"}})
+	if !errors.Is(err, ErrInvalidLocations) {
+		t.Fatalf("expected ErrInvalidLocations without agents, got %v", err)
+	}
+}
+
+// startRemoteTask creates a task and starts its run, which hands the remote
+// links to the hub.
+func startRemoteTask(t *testing.T, svc *Service, req models.LinkRequest) int {
+	t.Helper()
+	id, err := svc.CreateTask(context.Background(), req)
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	queued, _ := svc.queue.pop(context.Background())
+	NewWorkerPool(svc, 1).processTask(context.Background(), queued)
+	return id
+}
+
+func waitFinished(t *testing.T, svc *Service, id int) *models.Task {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if status, _ := svc.repo.Status(id); isFinished(status) {
+			break
+		}
+	}
+	task, _ := svc.GetTask(id)
+	return task
+}
+
+func TestAgentHub_FailsLinksWithoutAgent(t *testing.T) {
+	t.Parallel()
+
+	hub := NewAgentHub("token", 10*time.Millisecond)
+	svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 1, WithAgents(hub))
+	id := startRemoteTask(t, svc, models.LinkRequest{Links: []string{"https://intranet.example"}, Locations: []string{"office"}})
+
+	task := waitFinished(t, svc, id)
+	if task.Status != models.StatusDone || task.Results[0].Result != models.ResultNoAgent || task.Results[0].CheckTime.IsZero() {
+		t.Fatalf("link without an agent was not failed: %s %+v", task.Status, task.Results[0])
+	}
+}
+
+func TestAgentHub_CancelAndDeadlineFinishRemoteLinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		req    models.LinkRequest
+		cancel bool
+		status string
+		link   string
+	}{
+		{name: "cancel", cancel: true, status: models.StatusCancelled, link: models.StatusCancelled},
+		{name: "deadline", req: models.LinkRequest{MaxDurationSeconds: 1}, status: models.StatusPartial, link: models.StatusDeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			hub := NewAgentHub("token", time.Minute)
+			svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 1, WithAgents(hub))
+			agent, _ := hub.Register("office")
+
+			req := tt.req
+			req.Links, req.Locations = []string{"https://intranet.example"}, []string{"office"}
+			id := startRemoteTask(t, svc, req)
+			jobs, _ := hub.Poll(context.Background(), agent, 10)
+			if len(jobs) != 1 {
+				t.Fatalf("job was not dispatched: %+v", jobs)
+			}
+			if tt.cancel {
+				if err := svc.CancelTask(id); err != nil {
+					t.Fatalf("cancel: %v", err)
+				}
+			}
+
+			task := waitFinished(t, svc, id)
+			if task.Status != tt.status || task.Results[0].Status != tt.link {
+				t.Fatalf("unexpected task: %s %+v", task.Status, task.Results[0])
+			}
+			late := []models.AgentResult{{LeaseID: jobs[0].LeaseID, Result: models.LinkStatus{Status: models.StatusAvailable}}}
+			if accepted, _ := hub.Ack(agent, late); accepted != 0 {
+				t.Fatalf("result of a finished task was accepted")
+			}
+		})
+	}
+}
+
+func TestAgentHub_TracksChangesOfRemoteResults(t *testing.T) {
+	t.Parallel()
+
+	hub := NewAgentHub("token", time.Minute)
+	svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 1, WithAgents(hub))
+	agent, _ := hub.Register("office")
+
+	for i, hash := range []string{"v1", "v2"} {
+		id := startRemoteTask(t, svc, models.LinkRequest{
+			Links:        []string{"https://intranet.example"},
+			Locations:    []string{"office"},
+			CheckOptions: models.CheckOptions{TrackChanges: true},
+		})
+		jobs, _ := hub.Poll(context.Background(), agent, 10)
+		hub.Ack(agent, []models.AgentResult{{LeaseID: jobs[0].LeaseID, Result: models.LinkStatus{Status: models.StatusAvailable, ContentHash: hash}}})
+
+		res := waitFinished(t, svc, id).Results[0]
+		if res.ContentHash != hash || res.Changed != (i == 1) {
+			t.Fatalf("run %d: change not tracked: %+v", i, res)
+		}
+	}
+}
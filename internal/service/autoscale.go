@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAutoscaleInterval = 10 * time.Second
+	defaultAutoscaleDrain    = 30 * time.Second
+	// assumedLatency stands in for the check latency until one is measured.
+	assumedLatency = time.Second
+)
+
+type AutoscaleConfig struct {
+	Min int
+	Max int
+	// Interval between scaling decisions.
+	Interval time.Duration
+	// Drain is how fast the backlog should be worked off.
+	Drain time.Duration
+}
+
+// Autoscaler resizes a WorkerPool between Min and Max workers. It sizes the
+// pool so the current backlog is checked within Drain at the measured check
+// latency, and shrinks by at most half per step to avoid flapping.
+type Autoscaler struct {
+	pool *WorkerPool
+
+	mu  sync.Mutex
+	cfg AutoscaleConfig
+}
+
+func NewAutoscaler(pool *WorkerPool, cfg AutoscaleConfig) (*Autoscaler, error) {
+	if err := validateBounds(cfg.Min, cfg.Max); err != nil {
+		return nil, err
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultAutoscaleInterval
+	}
+	if cfg.Drain <= 0 {
+		cfg.Drain = defaultAutoscaleDrain
+	}
+	return &Autoscaler{pool: pool, cfg: cfg}, nil
+}
+
+func (a *Autoscaler) Run(ctx context.Context) {
+	a.step()
+
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.step()
+		}
+	}
+}
+
+func (a *Autoscaler) Bounds() (int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cfg.Min, a.cfg.Max
+}
+
+// SetBounds changes the limits; the pool follows on the next step.
+func (a *Autoscaler) SetBounds(min, max int) error {
+	if err := validateBounds(min, max); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.cfg.Min, a.cfg.Max = min, max
+	a.mu.Unlock()
+	a.step()
+	return nil
+}
+
+func (a *Autoscaler) step() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	current := a.pool.Size()
+	backlog, latency := a.pool.Backlog(), a.pool.Latency()
+	target := a.target(current, backlog, latency)
+	if target == current {
+		return
+	}
+	if err := a.pool.Resize(target); err != nil {
+		log.Printf("autoscale: resize to %d: %v", target, err)
+		return
+	}
+	log.Printf("autoscale: workers %d -> %d (backlog %d, latency %v)", current, target, backlog, latency)
+}
+
+func (a *Autoscaler) target(current, backlog int, latency time.Duration) int {
+	if latency <= 0 {
+		latency = assumedLatency
+	}
+	need := int((time.Duration(backlog)*latency + a.cfg.Drain - 1) / a.cfg.Drain)
+	if need < current {
+		need = max(need, current/2)
+	}
+	return min(max(need, a.cfg.Min), a.cfg.Max)
+}
+
+func validateBounds(min, max int) error {
+	if min < 1 || max < min {
+		return fmt.Errorf("%w: need 1 <= min <= max, got %d..%d", ErrInvalidPoolSize, min, max)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestAutoscaler_Target(t *testing.T) {
+	t.Parallel()
+
+	scaler, err := NewAutoscaler(nil, AutoscaleConfig{Min: 2, Max: 20, Drain: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("new autoscaler: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		current int
+		backlog int
+		latency time.Duration
+		want    int
+	}{
+		{name: "idle keeps minimum", current: 2, backlog: 0, latency: time.Second, want: 2},
+		{name: "backlog grows pool", current: 2, backlog: 50, latency: time.Second, want: 5},
+		{name: "slow checks need more workers", current: 2, backlog: 50, latency: 3 * time.Second, want: 15},
+		{name: "capped at maximum", current: 4, backlog: 1000, latency: time.Second, want: 20},
+		{name: "shrinks by half at most", current: 16, backlog: 0, latency: time.Second, want: 8},
+		{name: "no latency yet", current: 2, backlog: 30, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := scaler.target(tt.current, tt.backlog, tt.latency); got != tt.want {
+				t.Fatalf("target = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewAutoscaler(nil, AutoscaleConfig{Min: 5, Max: 2}); !errors.Is(err, ErrInvalidPoolSize) {
+		t.Fatalf("expected ErrInvalidPoolSize, got %v", err)
+	}
+}
+
+func TestWorkerPool_Resize(t *testing.T) {
+	t.Parallel()
+
+	checker := &recordingChecker{delay: 20 * time.Millisecond}
+	svc := NewService(repository.NewMemoryRepo(), checker, 1)
+	id, _ := svc.CreateTask(context.Background(), models.LinkRequest{Links: linkList("example.com", 12)})
+
+	pool := NewWorkerPool(svc, 1)
+	if err := pool.Resize(0); !errors.Is(err, ErrInvalidPoolSize) {
+		t.Fatalf("expected ErrInvalidPoolSize, got %v", err)
+	}
+	pool.Start(context.Background())
+	if err := pool.Resize(4); err != nil {
+		t.Fatalf("resize: %v", err)
+	}
+	svc.CloseQueue()
+	pool.Wait()
+
+	if pool.Size() != 4 || checker.peak < 2 {
+		t.Fatalf("pool did not grow: size %d, peak %d", pool.Size(), checker.peak)
+	}
+	task, _ := svc.GetTask(id)
+	if task.Status != models.StatusDone || len(checker.order) != 12 {
+		t.Fatalf("unexpected task state: %s after %d checks", task.Status, len(checker.order))
+	}
+	if pool.Latency() < 20*time.Millisecond {
+		t.Fatalf("latency not measured: %v", pool.Latency())
+	}
+
+	pool.Stop()
+	if err := pool.Resize(8); !errors.Is(err, ErrShuttingDown) || pool.Size() != 4 {
+		t.Fatalf("stopped pool resized: %v, size %d", err, pool.Size())
+	}
+}
+
+func TestWorkerPool_Shrink(t *testing.T) {
+	t.Parallel()
+
+	checker := &recordingChecker{delay: 10 * time.Millisecond}
+	svc := NewService(repository.NewMemoryRepo(), checker, 1)
+	pool := NewWorkerPool(svc, 4)
+	pool.Start(context.Background())
+	if err := pool.Resize(1); err != nil {
+		t.Fatalf("resize: %v", err)
+	}
+
+	id, _ := svc.CreateTask(context.Background(), models.LinkRequest{Links: linkList("example.com", 8)})
+	svc.CloseQueue()
+	pool.Wait()
+
+	if checker.peak != 1 {
+		t.Fatalf("removed workers kept checking: peak %d", checker.peak)
+	}
+	task, _ := svc.GetTask(id)
+	if task.Status != models.StatusDone {
+		t.Fatalf("task not done: %s", task.Status)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// failureTTL caps how long a failed check is served from the cache, so a
+// transient outage is retried soon.
+const failureTTL = 10 * time.Second
+
+type cacheEntry struct {
+	result    models.LinkStatus
+	expiresAt time.Time
+}
+
+// flight is a check in progress shared by every caller of the same key. The
+// check is cancelled once all of its callers have given up.
+type flight struct {
+	done    chan struct{}
+	result  models.LinkStatus
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// CachedChecker shares results between tasks: fresh results are served from
+// memory and concurrent checks of the same URL collapse into one request.
+type CachedChecker struct {
+	next    Checker
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	flights map[string]*flight
+	armed   bool
+}
+
+func NewCachedChecker(next Checker, ttl time.Duration) *CachedChecker {
+	return &CachedChecker{
+		next:    next,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		flights: make(map[string]*flight),
+	}
+}
+
+func (c *CachedChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	key := cacheKey(url, opts)
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && time.Now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		result := entry.result
+		result.Cached = true
+		return result
+	}
+	f, shared := c.flights[key]
+	if !shared {
+		checkCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		c.flights[key] = f
+		go c.run(checkCtx, key, url, opts, f)
+	}
+	f.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		result := f.result
+		if shared {
+			result.Cached = true
+		}
+		return result
+	case <-ctx.Done():
+		c.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+		}
+		c.mu.Unlock()
+		return models.LinkStatus{URL: url, Status: models.StatusNotAvailable, Error: ctx.Err().Error()}
+	}
+}
+
+func (c *CachedChecker) run(ctx context.Context, key, url string, opts models.CheckOptions, f *flight) {
+	result := c.next.Check(ctx, url, opts)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f.cancel()
+	f.result = result
+	close(f.done)
+	if c.flights[key] != f {
+		// Every caller gave up and the check was cancelled.
+		return
+	}
+	delete(c.flights, key)
+
+	// Pages are handed to the callers waiting now but not kept in memory.
+	result.Page = nil
+	ttl := c.ttl
+	if result.Status == models.StatusNotAvailable && ttl > failureTTL {
+		ttl = failureTTL
+	}
+	c.entries[key] = cacheEntry{result: result, expiresAt: now.Add(ttl)}
+	c.armLocked()
+}
+
+// armLocked schedules a sweep of expired entries while the cache holds any.
+func (c *CachedChecker) armLocked() {
+	if c.armed {
+		return
+	}
+	c.armed = true
+	time.AfterFunc(c.ttl, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.armed = false
+		now := time.Now()
+		for key, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) > 0 {
+			c.armLocked()
+		}
+	})
+}
+
+func cacheKey(url string, opts models.CheckOptions) string {
+	encoded, err := json.Marshal(opts)
+	if err != nil {
+		return url
+	}
+	return url + "\x00" + string(encoded)
+}
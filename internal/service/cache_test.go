@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+type countingChecker struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (c *countingChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	c.calls.Add(1)
+	time.Sleep(c.delay)
+	return models.LinkStatus{URL: url, Status: models.StatusAvailable, CheckTime: time.Now()}
+}
+
+func TestCachedChecker_CoalescesAndCaches(t *testing.T) {
+	t.Parallel()
+
+	next := &countingChecker{delay: 50 * time.Millisecond}
+	cache := NewCachedChecker(next, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Check(context.Background(), "https://example.com", models.CheckOptions{})
+		}()
+	}
+	wg.Wait()
+
+	if got := next.calls.Load(); got != 1 {
+		t.Fatalf("expected a single upstream check, got %d", got)
+	}
+
+	first := cache.Check(context.Background(), "https://example.com", models.CheckOptions{})
+	if !first.Cached {
+		t.Fatalf("expected cached result")
+	}
+	second := cache.Check(context.Background(), "https://example.com", models.CheckOptions{})
+	if !second.CheckTime.Equal(first.CheckTime) {
+		t.Fatalf("cached result should keep original check time")
+	}
+
+	withAuth := models.CheckOptions{Auth: &models.Auth{Type: models.AuthBearer, Secret: "token"}}
+	cache.Check(context.Background(), "https://example.com", withAuth)
+	if got := next.calls.Load(); got != 2 {
+		t.Fatalf("different options must not share cache entries, got %d calls", got)
+	}
+}
+
+// stallingChecker blocks until its context is cancelled and reports that.
+type stallingChecker struct {
+	cancelled chan struct{}
+}
+
+func (c *stallingChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	<-ctx.Done()
+	close(c.cancelled)
+	return models.LinkStatus{URL: url, Status: models.StatusNotAvailable, Error: ctx.Err().Error()}
+}
+
+func TestCachedChecker_CancelsAbandonedChecks(t *testing.T) {
+	t.Parallel()
+
+	next := &stallingChecker{cancelled: make(chan struct{})}
+	cache := NewCachedChecker(next, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan models.LinkStatus)
+	go func() { done <- cache.Check(ctx, "https://example.com", models.CheckOptions{}) }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-done
+
+	select {
+	case <-next.cancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("upstream check not cancelled after its caller left")
+	}
+	time.Sleep(10 * time.Millisecond)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(cache.entries) != 0 || len(cache.flights) != 0 {
+		t.Fatalf("abandoned check left state behind: %v %v", cache.entries, cache.flights)
+	}
+}
+
+type failingChecker struct{}
+
+func (failingChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	return models.LinkStatus{URL: url, Status: models.StatusNotAvailable, Result: models.ResultTimeout}
+}
+
+func TestCachedChecker_ExpiresFailuresAndSweeps(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCachedChecker(failingChecker{}, time.Hour)
+	cache.Check(context.Background(), "https://down.example", models.CheckOptions{})
+	cache.mu.Lock()
+	entry := cache.entries[cacheKey("https://down.example", models.CheckOptions{})]
+	cache.mu.Unlock()
+	if until := time.Until(entry.expiresAt); until > failureTTL {
+		t.Fatalf("failure cached for %v", until)
+	}
+
+	short := NewCachedChecker(&countingChecker{}, 20*time.Millisecond)
+	short.Check(context.Background(), "https://example.com", models.CheckOptions{})
+	time.Sleep(100 * time.Millisecond)
+	short.mu.Lock()
+	defer short.mu.Unlock()
+	if len(short.entries) != 0 {
+		t.Fatalf("expired entries not swept: %d", len(short.entries))
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// CancelTask stops a task. Queued tasks leave the queue, running ones have
+// their in-flight checks aborted; links that were not checked yet are marked
+// cancelled.
+func (s *Service) CancelTask(id int) error {
+	_, err := s.cancel(id)
+	return err
+}
+
+// DeleteTask cancels a task if needed and removes it once its in-flight
+// checks have returned.
+func (s *Service) DeleteTask(ctx context.Context, id int) error {
+	run, err := s.cancel(id)
+	if err != nil && !errors.Is(err, ErrTaskFinished) {
+		return err
+	}
+	if run != nil {
+		select {
+		case <-run.finished:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	s.repo.Delete(id)
+	return nil
+}
+
+// cancel stops a running task through its run. Otherwise the task is still
+// queued or was just popped; register then sees the stored cancelled status
+// and does not start it.
+func (s *Service) cancel(id int) (*taskRun, error) {
+	s.runsMu.Lock()
+	if run := s.runs[id]; run != nil {
+		run.mu.Lock()
+		done := run.done
+		if !done {
+			run.cancelled.Store(true)
+		}
+		run.mu.Unlock()
+		s.runsMu.Unlock()
+
+		// A completing run is unregistered only after its final status is
+		// stored; callers may still wait for it.
+		if done {
+			return run, ErrTaskFinished
+		}
+		run.cancel()
+		run.pool.settle(run)
+		return run, nil
+	}
+	defer s.runsMu.Unlock()
+
+	status, ok := s.repo.Status(id)
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if isFinished(status) {
+		return nil, ErrTaskFinished
+	}
+	task, queued := s.queue.remove(id)
+	if !queued {
+		if task, ok = s.repo.Get(id); !ok {
+			return nil, ErrTaskNotFound
+		}
+	}
+	s.finishCancelled(task)
+	return nil, nil
+}
+
+// register records a started run so it can be cancelled. It fails when the
+// task was cancelled or deleted after leaving the queue.
+func (s *Service) register(run *taskRun) bool {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	if status, ok := s.repo.Status(run.task.ID); !ok || status == models.StatusCancelled {
+		return false
+	}
+	run.task.Status = models.StatusProcessing
+	s.runs[run.task.ID] = run
+	return true
+}
+
+func (s *Service) unregister(run *taskRun) {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+	delete(s.runs, run.task.ID)
+}
+
+func (s *Service) finishCancelled(task *models.Task) {
+	markCancelled(task)
+	s.repo.Save(task)
+	s.taskFinished(task)
+}
+
+// markCancelled sets the cancelled status on the task and every link that
+// was not checked yet.
+func markCancelled(task *models.Task) {
+	for i := range task.Results {
+		res := &task.Results[i]
+		if res.Status == models.StatusPending || res.Status == models.StatusProcessing {
+			res.Status = models.StatusCancelled
+			res.Availability = ""
+		}
+	}
+	task.Status = models.StatusCancelled
+	recordBudget(task)
+}
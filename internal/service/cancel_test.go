@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// blockingChecker holds every check until its context is cancelled.
+type blockingChecker struct {
+	started chan string
+}
+
+func (c *blockingChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	c.started <- url
+	<-ctx.Done()
+	return models.LinkStatus{URL: url, Status: models.StatusNotAvailable, Error: ctx.Err().Error()}
+}
+
+func TestCancelTask_Queued(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo, err := repository.NewPersistentRepo(path)
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	svc := NewService(repo, fakeSite{}, 4)
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"example.com", "example.org"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	if err := svc.CancelTask(id); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := svc.CancelTask(id); !errors.Is(err, ErrTaskFinished) {
+		t.Fatalf("second cancel: %v", err)
+	}
+	if svc.QueuePosition(id) != 0 {
+		t.Fatalf("cancelled task still queued")
+	}
+
+	reopened, err := repository.NewPersistentRepo(path)
+	if err != nil {
+		t.Fatalf("reopen repo: %v", err)
+	}
+	restarted := NewService(reopened, fakeSite{}, 4)
+	if restarted.QueuePosition(id) != 0 {
+		t.Fatalf("cancelled task re-queued after restart")
+	}
+	task, _ := restarted.GetTask(id)
+	if task.Status != models.StatusCancelled {
+		t.Fatalf("status = %q", task.Status)
+	}
+	for _, res := range task.Results {
+		if res.Status != models.StatusCancelled {
+			t.Fatalf("link not cancelled: %+v", res)
+		}
+	}
+}
+
+func TestCancelTask_AbortsRunningChecks(t *testing.T) {
+	t.Parallel()
+
+	checker := &blockingChecker{started: make(chan string, 1)}
+	svc := NewService(repository.NewMemoryRepo(), checker, 4)
+	pool := NewWorkerPool(svc, 1)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"a.example", "b.example", "c.example"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	select {
+	case <-checker.started:
+	case <-time.After(time.Second):
+		t.Fatalf("check did not start")
+	}
+
+	if err := svc.DeleteTask(context.Background(), id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := svc.GetTask(id); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("deleted task still stored: %v", err)
+	}
+	select {
+	case url := <-checker.started:
+		t.Fatalf("check of %s started after cancellation", url)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCancelTask_MarksUncheckedLinks(t *testing.T) {
+	t.Parallel()
+
+	checker := &blockingChecker{started: make(chan string, 1)}
+	svc := NewService(repository.NewMemoryRepo(), checker, 4)
+	pool := NewWorkerPool(svc, 1)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"a.example", "b.example"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	<-checker.started
+
+	if err := svc.CancelTask(id); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	svc.runsMu.Lock()
+	run := svc.runs[id]
+	svc.runsMu.Unlock()
+	if run != nil {
+		<-run.finished
+	}
+
+	task, _ := svc.GetTask(id)
+	if task.Status != models.StatusCancelled {
+		t.Fatalf("status = %q", task.Status)
+	}
+	for _, res := range task.Results {
+		if res.Status != models.StatusCancelled || res.Availability != "" {
+			t.Fatalf("link not cancelled: %+v", res)
+		}
+	}
+}
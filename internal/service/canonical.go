@@ -0,0 +1,49 @@
+package service
+
+import (
+	"github.com/whiterage/14-11-2025/pkg/models"
+	"github.com/whiterage/14-11-2025/pkg/urlnorm"
+)
+
+// resultSet builds task results keyed by canonical URL, so spelling variants
+// of one link are checked once and every original input is kept.
+type resultSet struct {
+	opts  urlnorm.Options
+	items []models.LinkStatus
+	index map[string]int
+}
+
+func newResultSet(capacity int, stripTracking bool) *resultSet {
+	return &resultSet{
+		opts:  urlnorm.Options{StripTracking: stripTracking},
+		items: make([]models.LinkStatus, 0, capacity),
+		index: make(map[string]int, capacity),
+	}
+}
+
+func (r *resultSet) add(input string, status models.LinkStatus) {
+	canonical, err := urlnorm.Canonical(input, r.opts)
+	if err != nil {
+		// Invalid input is kept verbatim and reported by the worker.
+		canonical = input
+	}
+
+	if i, ok := r.index[canonical]; ok {
+		r.items[i].Inputs = appendUnique(r.items[i].Inputs, input)
+		return
+	}
+
+	status.URL = canonical
+	status.Inputs = []string{input}
+	r.index[canonical] = len(r.items)
+	r.items = append(r.items, status)
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+	"github.com/whiterage/14-11-2025/pkg/textdiff"
+)
+
+const (
+	maxDiffSize = 64 << 10
+	// maxSnapshotText caps the page text kept in storage; diffs cover only
+	// this leading part of a page.
+	maxSnapshotText = 256 << 10
+)
+
+// trackChanges compares a fresh content hash with the previous check of the
+// same URL and stores the new snapshot when the content is new or changed.
+// Agents keep a snapshot per location, as sites may differ between networks,
+// and checks sending other headers, auth or steps keep their own, as they
+// may see another version of the page.
+func (s *Service) trackChanges(task *models.Task, res *models.LinkStatus, url string, result models.LinkStatus) {
+	res.ContentHash = result.ContentHash
+
+	checkTime := result.CheckTime
+	if checkTime.IsZero() {
+		checkTime = clock.Now()
+	}
+
+	key := url
+	if isRemote(*res) {
+		key += "@" + res.Location
+	}
+	if fingerprint := optionsFingerprint(task.Options); fingerprint != "" {
+		key += "|" + fingerprint
+	}
+	text := snapshotText(result.ContentText)
+	prev, ok := s.repo.Snapshot(key)
+	if ok && prev.Hash == result.ContentHash {
+		// Text tracking may have been off when the snapshot was taken; keep
+		// the text now so the next change gets a diff.
+		if task.Options.TrackText && prev.Text == "" && text != "" {
+			prev.Text = text
+			s.repo.SaveSnapshot(key, prev)
+		}
+		return
+	}
+
+	if ok {
+		res.Changed = true
+		if prev.Text != "" && task.Options.TrackText {
+			res.Diff = truncateDiff(textdiff.Unified(
+				prev.Text,
+				text,
+				fmt.Sprintf("%s\t%s", url, prev.CheckTime.Format(time.RFC3339)),
+				fmt.Sprintf("%s\t%s", url, checkTime.Format(time.RFC3339)),
+			))
+		}
+	}
+
+	snap := models.ContentSnapshot{
+		Hash:      result.ContentHash,
+		CheckTime: checkTime,
+		TaskID:    task.ID,
+	}
+	if task.Options.TrackText {
+		snap.Text = text
+	}
+	s.repo.SaveSnapshot(key, snap)
+}
+
+// optionsFingerprint identifies the options that change what a check gets
+// back: headers, auth and transaction steps. Only secret names are part of
+// the options, never their values. Checks without any share the empty
+// fingerprint.
+func optionsFingerprint(opts models.CheckOptions) string {
+	if len(opts.Headers) == 0 && opts.Auth == nil && opts.Transaction == nil {
+		return ""
+	}
+	encoded, err := json.Marshal(models.CheckOptions{
+		Headers:     opts.Headers,
+		Auth:        opts.Auth,
+		Transaction: opts.Transaction,
+	})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8])
+}
+
+// snapshotText cuts text to maxSnapshotText at a line break when there is
+// one.
+func snapshotText(text string) string {
+	if len(text) <= maxSnapshotText {
+		return text
+	}
+	cut := maxSnapshotText
+	if i := strings.LastIndexByte(text[:cut], '\n'); i > 0 {
+		return text[:i+1]
+	}
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
+
+func truncateDiff(diff string) string {
+	if len(diff) <= maxDiffSize {
+		return diff
+	}
+	cut := strings.LastIndexByte(diff[:maxDiffSize], '\n') + 1
+	return diff[:cut] + "... diff truncated ...\n"
+}
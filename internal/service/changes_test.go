@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+type contentChecker struct {
+	text string
+}
+
+func (c *contentChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	return models.LinkStatus{
+		URL:         url,
+		Status:      models.StatusAvailable,
+		ContentHash: "hash:" + c.text,
+		ContentText: c.text,
+	}
+}
+
+func TestWorkerPool_TracksContentChanges(t *testing.T) {
+	t.Parallel()
+
+	checker := &contentChecker{text: "Privacy policy\nVersion 1"}
+	svc := NewService(repository.NewMemoryRepo(), checker, 2)
+	pool := NewWorkerPool(svc, 1)
+
+	req := models.LinkRequest{
+		Links:        []string{"example.com/privacy"},
+		CheckOptions: models.CheckOptions{TrackChanges: true, TrackText: true},
+	}
+
+	run := func() models.LinkStatus {
+		id, err := svc.CreateTask(context.Background(), req)
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		queued, _ := svc.queue.pop(context.Background())
+		pool.processTask(context.Background(), queued)
+		task, _ := svc.GetTask(id)
+		return task.Results[0]
+	}
+
+	if first := run(); first.Changed {
+		t.Fatalf("first check cannot be a change")
+	}
+	if same := run(); same.Changed {
+		t.Fatalf("unchanged content flagged as changed")
+	}
+
+	checker.text = "Privacy policy\nVersion 2"
+	changed := run()
+	if !changed.Changed {
+		t.Fatalf("content change not detected")
+	}
+	if !strings.Contains(changed.Diff, "-Version 1\n+Version 2\n") {
+		t.Fatalf("unexpected diff:\n%s", changed.Diff)
+	}
+}
+
+func TestWorkerPool_FillsSnapshotTextLater(t *testing.T) {
+	t.Parallel()
+
+	checker := &contentChecker{text: "Terms\nVersion 1"}
+	svc := NewService(repository.NewMemoryRepo(), checker, 3)
+	pool := NewWorkerPool(svc, 1)
+
+	run := func(trackText bool) models.LinkStatus {
+		id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+			Links:        []string{"example.com/terms"},
+			CheckOptions: models.CheckOptions{TrackChanges: true, TrackText: trackText},
+		})
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		queued, _ := svc.queue.pop(context.Background())
+		pool.processTask(context.Background(), queued)
+		task, _ := svc.GetTask(id)
+		return task.Results[0]
+	}
+
+	run(false)
+	run(true)
+	checker.text = "Terms\nVersion 2"
+	if changed := run(true); !strings.Contains(changed.Diff, "-Version 1\n+Version 2\n") {
+		t.Fatalf("no diff against a snapshot taken without text:\n%s", changed.Diff)
+	}
+}
+
+// localizedChecker serves a page whose text depends on the X-Lang header.
+type localizedChecker struct{}
+
+func (localizedChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	text := "Welcome"
+	for _, h := range opts.Headers {
+		if h.Name == "X-Lang" && h.Value == "de" {
+			text = "Willkommen"
+		}
+	}
+	return models.LinkStatus{URL: url, Status: models.StatusAvailable, ContentHash: "hash:" + text, ContentText: text}
+}
+
+func TestWorkerPool_KeepsSnapshotsPerOptions(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(repository.NewMemoryRepo(), localizedChecker{}, 4)
+	pool := NewWorkerPool(svc, 1)
+
+	run := func(headers []models.Header) models.LinkStatus {
+		id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+			Links:        []string{"example.com"},
+			CheckOptions: models.CheckOptions{TrackChanges: true, Headers: headers},
+		})
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		queued, _ := svc.queue.pop(context.Background())
+		pool.processTask(context.Background(), queued)
+		task, _ := svc.GetTask(id)
+		return task.Results[0]
+	}
+
+	german := []models.Header{{Name: "X-Lang", Value: "de"}}
+	for i, headers := range [][]models.Header{nil, german, nil, german} {
+		if res := run(headers); res.Changed {
+			t.Fatalf("check %d compared with a snapshot taken with other headers", i)
+		}
+	}
+}
+
+func TestSnapshotText(t *testing.T) {
+	t.Parallel()
+
+	short := "line 1\nline 2"
+	if got := snapshotText(short); got != short {
+		t.Fatalf("short text changed: %q", got)
+	}
+
+	long := strings.Repeat("line of text\n", maxSnapshotText/13+10)
+	got := snapshotText(long)
+	if len(got) > maxSnapshotText || !strings.HasSuffix(got, "\n") || !strings.HasPrefix(long, got) {
+		t.Fatalf("long text not cut at a line break: %d bytes", len(got))
+	}
+
+	runes := strings.Repeat("ü", maxSnapshotText)
+	if got := snapshotText(runes); len(got) > maxSnapshotText || !utf8.ValidString(got) {
+		t.Fatalf("text without line breaks cut inside a character")
+	}
+}
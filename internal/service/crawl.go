@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+
+	"github.com/whiterage/14-11-2025/pkg/htmllinks"
+	"github.com/whiterage/14-11-2025/pkg/models"
+	"github.com/whiterage/14-11-2025/pkg/urlnorm"
+)
+
+const (
+	defaultCrawlDepth = 2
+	defaultCrawlPages = 50
+	maxCrawlDepth     = 10
+	maxCrawlPages     = 1000
+	maxCrawlLinks     = 10000
+)
+
+func normalizeCrawlOptions(opts models.CrawlOptions) (models.CrawlOptions, error) {
+	if opts.MaxDepth < 0 || opts.MaxDepth > maxCrawlDepth {
+		return opts, fmt.Errorf("%w: max_depth must be between 0 and %d", ErrInvalidCrawl, maxCrawlDepth)
+	}
+	if opts.MaxPages < 0 || opts.MaxPages > maxCrawlPages {
+		return opts, fmt.Errorf("%w: max_pages must be between 0 and %d", ErrInvalidCrawl, maxCrawlPages)
+	}
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = defaultCrawlDepth
+	}
+	if opts.MaxPages == 0 {
+		opts.MaxPages = defaultCrawlPages
+	}
+	return opts, nil
+}
+
+type crawlState struct {
+	opts     models.CrawlOptions
+	origins  map[string]bool
+	seen     map[string]bool
+	pages    int
+	fetching int
+}
+
+func newCrawlState(task *models.Task) *crawlState {
+	state := &crawlState{
+		opts:    *task.Crawl,
+		origins: make(map[string]bool),
+		seen:    make(map[string]bool, len(task.Results)),
+	}
+
+	for _, res := range task.Results {
+		resolved, err := crawlCanonical(task, res.URL)
+		if err != nil {
+			continue
+		}
+		state.seen[resolved] = true
+		if res.Depth == 0 {
+			state.origins[originOf(resolved)] = true
+		}
+	}
+	return state
+}
+
+// mayExpand reports whether a link could be crawled once it turns out to be
+// available, so its check should keep the page. Callers hold the run's lock.
+func (c *crawlState) mayExpand(res models.LinkStatus, pageURL string) bool {
+	return res.Depth < c.opts.MaxDepth && c.origins[originOf(pageURL)]
+}
+
+// reserve reports whether an available same-origin page should be crawled.
+// The page holds a slot of the page limit while it is fetched; see
+// finishPage. Callers hold the run's lock.
+func (c *crawlState) reserve(res models.LinkStatus, pageURL string) bool {
+	if res.Status != models.StatusAvailable || res.Depth >= c.opts.MaxDepth || c.pages+c.fetching >= c.opts.MaxPages {
+		return false
+	}
+	if !c.origins[originOf(pageURL)] {
+		return false
+	}
+	c.fetching++
+	return true
+}
+
+// finishPage releases the slot of a reserved page and reports whether the
+// links of the page, which ended up at finalURL after redirects, are
+// followed. A start page adds the origin it redirects to, so
+// "http://example.com" crawls "https://www.example.com"; other pages must
+// stay on a crawled origin. Only followed pages count against the page
+// limit. Callers hold the run's lock.
+func (c *crawlState) finishPage(task *models.Task, i int, finalURL string, parsed bool) bool {
+	c.fetching--
+	if !parsed {
+		return false
+	}
+	final, err := crawlCanonical(task, finalURL)
+	if err != nil {
+		return false
+	}
+	if task.Results[i].Depth == 0 {
+		c.origins[originOf(final)] = true
+	}
+	if !c.origins[originOf(final)] {
+		return false
+	}
+	c.pages++
+	return true
+}
+
+// fetchLinks extracts the links of a reserved page, downloading it unless
+// the check kept it, and returns them with the URL the page was finally
+// loaded from; ok is false when the page could not be fetched or is not
+// HTML. It runs without the run's lock so other checks of the task keep
+// going.
+func (c *crawlState) fetchLinks(ctx context.Context, fetcher Fetcher, opts models.CheckOptions, pageURL string, page *models.Page) ([]htmllinks.Link, string, bool) {
+	if page == nil {
+		var err error
+		if page, err = fetcher.Fetch(ctx, pageURL, opts); err != nil {
+			return nil, "", false
+		}
+	}
+	if !isHTML(page.ContentType) {
+		return nil, "", false
+	}
+
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return nil, "", false
+	}
+	links, err := htmllinks.Extract(base, bytes.NewReader(page.Body))
+	if err != nil {
+		return nil, "", false
+	}
+	return links, page.URL, true
+}
+
+// add appends every link not seen yet, remembering where it was found.
+// Callers hold the run's lock.
+func (c *crawlState) add(task *models.Task, links []htmllinks.Link, i int, pageURL string) int {
+	depth := task.Results[i].Depth
+	added := 0
+	for _, link := range links {
+		if len(task.Results) >= maxCrawlLinks {
+			break
+		}
+		canonical, err := crawlCanonical(task, link.URL)
+		if err != nil || c.seen[canonical] {
+			continue
+		}
+		c.seen[canonical] = true
+		task.Results = append(task.Results, models.LinkStatus{
+			URL:        canonical,
+			Status:     models.StatusPending,
+			Referrer:   pageURL,
+			AnchorText: link.Text,
+			Depth:      depth + 1,
+		})
+		added++
+	}
+	return added
+}
+
+// crawlCanonical is the form in which crawled links are stored and
+// deduplicated.
+func crawlCanonical(task *models.Task, raw string) (string, error) {
+	return urlnorm.Canonical(raw, urlnorm.Options{StripTracking: task.Options.StripTracking})
+}
+
+func originOf(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
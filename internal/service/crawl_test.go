@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/htmllinks"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+type fakeSite map[string]string
+
+func (s fakeSite) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	if _, ok := s[url]; !ok {
+		return models.LinkStatus{URL: url, Status: models.StatusNotAvailable}
+	}
+	return models.LinkStatus{URL: url, Status: models.StatusAvailable}
+}
+
+func (s fakeSite) Fetch(ctx context.Context, url string, opts models.CheckOptions) (*models.Page, error) {
+	return &models.Page{URL: url, StatusCode: 200, ContentType: "text/html; charset=utf-8", Body: []byte(s[url])}, nil
+}
+
+func TestWorkerPool_CrawlTask(t *testing.T) {
+	t.Parallel()
+
+	site := fakeSite{
+		"https://docs.example.com":            `<a href="/guide">Guide</a><a href="https://other.org/">Other</a>`,
+		"https://docs.example.com/guide":      `<a href="/missing">Broken link</a><a href="/guide/deep">Deep</a>`,
+		"https://docs.example.com/guide/deep": `<a href="/too-deep">Too deep</a>`,
+		"https://other.org/":                  `<a href="/external-page">not followed</a>`,
+	}
+
+	svc := NewService(repository.NewMemoryRepo(), site, 1, WithFetcher(site))
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+		Links: []string{"docs.example.com"},
+		Crawl: &models.CrawlOptions{MaxDepth: 2},
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	pool := NewWorkerPool(svc, 1)
+	queued, _ := svc.queue.pop(context.Background())
+	pool.processTask(context.Background(), queued)
+
+	task, _ := svc.GetTask(id)
+	got := make(map[string]models.LinkStatus, len(task.Results))
+	for _, res := range task.Results {
+		got[res.URL] = res
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("unexpected results: %+v", task.Results)
+	}
+	missing, ok := got["https://docs.example.com/missing"]
+	if !ok || missing.Status != models.StatusNotAvailable {
+		t.Fatalf("broken link not reported: %+v", missing)
+	}
+	if missing.Referrer != "https://docs.example.com/guide" || missing.AnchorText != "Broken link" {
+		t.Fatalf("unexpected referrer info: %+v", missing)
+	}
+	if _, ok := got["https://other.org/external-page"]; ok {
+		t.Fatalf("external pages must not be followed")
+	}
+	if _, ok := got["https://docs.example.com/too-deep"]; ok {
+		t.Fatalf("depth limit exceeded")
+	}
+}
+
+type flakySite struct {
+	fakeSite
+	broken map[string]bool
+}
+
+func (s flakySite) Fetch(ctx context.Context, url string, opts models.CheckOptions) (*models.Page, error) {
+	if s.broken[url] {
+		return nil, errors.New("connection reset")
+	}
+	return s.fakeSite.Fetch(ctx, url, opts)
+}
+
+func TestWorkerPool_CrawlCountsOnlyParsedPages(t *testing.T) {
+	t.Parallel()
+
+	site := flakySite{
+		fakeSite: fakeSite{
+			"https://docs.example.com":   `<a href="/a">A</a><a href="/b">B</a>`,
+			"https://docs.example.com/a": ``,
+			"https://docs.example.com/b": `<a href="/c">C</a>`,
+			"https://docs.example.com/c": ``,
+		},
+		broken: map[string]bool{"https://docs.example.com/a": true},
+	}
+
+	svc := NewService(repository.NewMemoryRepo(), site, 1, WithFetcher(site))
+	id, _ := svc.CreateTask(context.Background(), models.LinkRequest{
+		Links: []string{"docs.example.com"},
+		Crawl: &models.CrawlOptions{MaxDepth: 2, MaxPages: 2},
+	})
+	queued, _ := svc.queue.pop(context.Background())
+	NewWorkerPool(svc, 1).processTask(context.Background(), queued)
+
+	task, _ := svc.GetTask(id)
+	found := false
+	for _, res := range task.Results {
+		found = found || res.URL == "https://docs.example.com/c"
+	}
+	if !found {
+		t.Fatalf("a failed fetch used up the page limit: %+v", task.Results)
+	}
+}
+
+// keepingSite returns pages from its checks and counts separate fetches.
+type keepingSite struct {
+	fakeSite
+	fetches *atomic.Int32
+}
+
+func (s keepingSite) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	result := s.fakeSite.Check(ctx, url, opts)
+	if opts.KeepPage && result.Status == models.StatusAvailable {
+		result.Page, _ = s.fakeSite.Fetch(ctx, url, opts)
+	}
+	return result
+}
+
+func (s keepingSite) Fetch(ctx context.Context, url string, opts models.CheckOptions) (*models.Page, error) {
+	s.fetches.Add(1)
+	return s.fakeSite.Fetch(ctx, url, opts)
+}
+
+func TestWorkerPool_CrawlReusesCheckedPages(t *testing.T) {
+	t.Parallel()
+
+	site := keepingSite{
+		fakeSite: fakeSite{
+			"https://docs.example.com/?ref=home": `<a href="/?utm_source=nav&ref=home">Home</a><a href="/guide">Guide</a>`,
+			"https://docs.example.com/guide":     `<a href="/?ref=home&utm_medium=footer">Home</a>`,
+		},
+		fetches: new(atomic.Int32),
+	}
+
+	svc := NewService(repository.NewMemoryRepo(), site, 1, WithFetcher(site))
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+		Links:        []string{"https://docs.example.com/?ref=home&utm_source=mail"},
+		Crawl:        &models.CrawlOptions{MaxDepth: 2},
+		CheckOptions: models.CheckOptions{StripTracking: true},
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	queued, _ := svc.queue.pop(context.Background())
+	NewWorkerPool(svc, 1).processTask(context.Background(), queued)
+
+	task, _ := svc.GetTask(id)
+	if len(task.Results) != 2 {
+		t.Fatalf("start page crawled again or guide missed: %+v", task.Results)
+	}
+	if got := site.fetches.Load(); got != 0 {
+		t.Fatalf("checked pages downloaded again %d times", got)
+	}
+}
+
+func TestCrawlState_SeedsWithCrawlCanonicalForm(t *testing.T) {
+	t.Parallel()
+
+	task := &models.Task{
+		Options: models.CheckOptions{StripTracking: true},
+		Crawl:   &models.CrawlOptions{MaxDepth: 2, MaxPages: 10},
+		Results: []models.LinkStatus{{URL: "https://docs.example.com/?utm_source=mail"}},
+	}
+	state := newCrawlState(task)
+	links := []htmllinks.Link{{URL: "https://docs.example.com/?utm_campaign=nav"}, {URL: "https://docs.example.com/guide"}}
+	if added := state.add(task, links, 0, task.Results[0].URL); added != 1 {
+		t.Fatalf("start page queued again: %+v", task.Results)
+	}
+}
+
+// redirectingSite serves pages of fakeSite from the URLs that redirect to
+// them.
+type redirectingSite struct {
+	fakeSite
+	redirects map[string]string
+}
+
+func (s redirectingSite) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	if target, ok := s.redirects[url]; ok {
+		url = target
+	}
+	return s.fakeSite.Check(ctx, url, opts)
+}
+
+func (s redirectingSite) Fetch(ctx context.Context, url string, opts models.CheckOptions) (*models.Page, error) {
+	if target, ok := s.redirects[url]; ok {
+		url = target
+	}
+	return s.fakeSite.Fetch(ctx, url, opts)
+}
+
+func TestWorkerPool_CrawlFollowsRedirectedSeed(t *testing.T) {
+	t.Parallel()
+
+	site := redirectingSite{
+		fakeSite: fakeSite{
+			"https://www.example.com/":      `<a href="/guide">Guide</a>`,
+			"https://www.example.com/guide": `<a href="/guide/deep">Deep</a><a href="https://other.org/">Other</a>`,
+			"https://other.org/":            `<a href="/external-page">not followed</a>`,
+		},
+		redirects: map[string]string{"http://example.com": "https://www.example.com/"},
+	}
+
+	svc := NewService(repository.NewMemoryRepo(), site, 1, WithFetcher(site))
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+		Links: []string{"http://example.com"},
+		Crawl: &models.CrawlOptions{MaxDepth: 3},
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	queued, _ := svc.queue.pop(context.Background())
+	NewWorkerPool(svc, 1).processTask(context.Background(), queued)
+
+	task, _ := svc.GetTask(id)
+	got := make(map[string]bool, len(task.Results))
+	for _, res := range task.Results {
+		got[res.URL] = true
+	}
+	if !got["https://www.example.com/guide"] || !got["https://www.example.com/guide/deep"] {
+		t.Fatalf("the origin the seed redirects to was not crawled: %+v", task.Results)
+	}
+	if got["https://other.org/external-page"] {
+		t.Fatalf("external pages must not be followed")
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var ErrInvalidDeadline = errors.New("invalid deadline")
+
+// taskBudget resolves the time limit of a new task; nil means no limit.
+func taskBudget(req models.LinkRequest, created time.Time) (*models.Budget, error) {
+	if req.MaxDurationSeconds < 0 {
+		return nil, fmt.Errorf("%w: max_duration_seconds must be positive", ErrInvalidDeadline)
+	}
+
+	var deadline time.Time
+	if req.Deadline != nil {
+		if !req.Deadline.After(created) {
+			return nil, fmt.Errorf("%w: deadline is in the past", ErrInvalidDeadline)
+		}
+		deadline = req.Deadline.In(clock.Location())
+	}
+	if req.MaxDurationSeconds > 0 {
+		limit := created.Add(time.Duration(req.MaxDurationSeconds) * time.Second)
+		if deadline.IsZero() || limit.Before(deadline) {
+			deadline = limit
+		}
+	}
+	if deadline.IsZero() {
+		return nil, nil
+	}
+	return &models.Budget{Deadline: deadline}, nil
+}
+
+// finishTask stores the final status: done, or partial when some links ran
+// out of time.
+func finishTask(task *models.Task) {
+	task.Status = models.StatusDone
+	for _, res := range task.Results {
+		if res.Status == models.StatusDeadlineExceeded {
+			task.Status = models.StatusPartial
+			break
+		}
+	}
+	recordBudget(task)
+}
+
+func recordBudget(task *models.Task) {
+	if task.Budget != nil {
+		task.Budget.Used = float64(clock.Now().Sub(task.CreatedAt)) / float64(time.Millisecond)
+	}
+}
+
+func deadlinePassed(task *models.Task, now time.Time) bool {
+	return task.Budget != nil && !now.Before(task.Budget.Deadline)
+}
+
+// markExpired reports unchecked links, local and remote, as out of time.
+func markExpired(task *models.Task) {
+	for i := range task.Results {
+		res := &task.Results[i]
+		if res.Status == models.StatusPending || res.Status == models.StatusProcessing {
+			res.Status = models.StatusDeadlineExceeded
+			res.Availability = ""
+		}
+	}
+}
+
+func isFinished(status string) bool {
+	switch status {
+	case models.StatusDone, models.StatusPartial, models.StatusCancelled:
+		return true
+	}
+	return false
+}
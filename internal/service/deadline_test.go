@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestTaskBudget(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2025, 11, 14, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		deadline := created.Add(d)
+		return &deadline
+	}
+
+	tests := []struct {
+		name    string
+		req     models.LinkRequest
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "no limit", req: models.LinkRequest{}},
+		{name: "max duration", req: models.LinkRequest{MaxDurationSeconds: 90}, want: 90 * time.Second},
+		{name: "deadline", req: models.LinkRequest{Deadline: at(time.Hour)}, want: time.Hour},
+		{name: "earlier wins", req: models.LinkRequest{Deadline: at(time.Hour), MaxDurationSeconds: 60}, want: time.Minute},
+		{name: "past deadline", req: models.LinkRequest{Deadline: at(-time.Second)}, wantErr: true},
+		{name: "negative duration", req: models.LinkRequest{MaxDurationSeconds: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			budget, err := taskBudget(tt.req, created)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDeadline) {
+					t.Fatalf("expected ErrInvalidDeadline, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == 0 {
+				if budget != nil {
+					t.Fatalf("unexpected budget: %+v", budget)
+				}
+				return
+			}
+			if budget == nil || !budget.Deadline.Equal(created.Add(tt.want)) {
+				t.Fatalf("deadline = %+v, want %v", budget, created.Add(tt.want))
+			}
+		})
+	}
+}
+
+func TestWorkerPool_DeadlineExceeded(t *testing.T) {
+	t.Parallel()
+
+	checker := &blockingChecker{started: make(chan string, 1)}
+	svc := NewService(repository.NewMemoryRepo(), checker, 4)
+	pool := NewWorkerPool(svc, 1)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	deadline := time.Now().Add(100 * time.Millisecond)
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+		Links:    []string{"a.example", "b.example", "c.example"},
+		Deadline: &deadline,
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	<-checker.started
+	svc.runsMu.Lock()
+	run := svc.runs[id]
+	svc.runsMu.Unlock()
+	select {
+	case <-run.finished:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("task did not stop at its deadline")
+	}
+
+	task, _ := svc.GetTask(id)
+	if task.Status != models.StatusPartial {
+		t.Fatalf("status = %q", task.Status)
+	}
+	for _, res := range task.Results {
+		if res.Status != models.StatusDeadlineExceeded {
+			t.Fatalf("link not marked: %+v", res)
+		}
+	}
+	if task.Budget == nil || task.Budget.Used < 100 {
+		t.Fatalf("budget not reported: %+v", task.Budget)
+	}
+}
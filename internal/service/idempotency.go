@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var (
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	ErrIdempotencyMismatch   = errors.New("idempotency key was used with a different request")
+	ErrIdempotencyInFlight   = errors.New("a request with this idempotency key is in progress")
+)
+
+const (
+	defaultIdempotencyTTL = 24 * time.Hour
+	maxIdempotencyKeyLen  = 255
+)
+
+// WithIdempotencyTTL sets how long an idempotency key is remembered.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		if ttl > 0 {
+			s.idempotencyTTL = ttl
+		}
+	}
+}
+
+// CreateTaskIdempotent creates a task like CreateTask. A repeated key with
+// the same request returns the task created the first time and replayed is
+// true; an empty key disables the check.
+func (s *Service) CreateTaskIdempotent(ctx context.Context, key string, req models.LinkRequest) (id int, replayed bool, err error) {
+	if key == "" {
+		id, err = s.CreateTask(ctx, req)
+		return id, false, err
+	}
+	if err := validateIdempotencyKey(key); err != nil {
+		return 0, false, err
+	}
+	hash, err := requestHash(req)
+	if err != nil {
+		return 0, false, err
+	}
+
+	s.idemMu.Lock()
+	s.repo.ExpireIdempotencyKeys(clock.Now().Add(-s.idempotencyTTL))
+	if entry, ok := s.repo.IdempotencyKey(key); ok {
+		// A key whose task was deleted is free again.
+		if _, err := s.GetTask(entry.TaskID); err == nil {
+			s.idemMu.Unlock()
+			if entry.RequestHash != hash {
+				return 0, false, ErrIdempotencyMismatch
+			}
+			return entry.TaskID, true, nil
+		}
+	}
+	if _, busy := s.idemInFlight[key]; busy {
+		s.idemMu.Unlock()
+		return 0, false, ErrIdempotencyInFlight
+	}
+	s.idemInFlight[key] = struct{}{}
+	s.idemMu.Unlock()
+
+	defer func() {
+		s.idemMu.Lock()
+		delete(s.idemInFlight, key)
+		s.idemMu.Unlock()
+	}()
+
+	id, err = s.createTask(ctx, req, 0, &models.IdempotencyKey{Key: key, RequestHash: hash})
+	return id, false, err
+}
+
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLen {
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidIdempotencyKey, maxIdempotencyKeyLen)
+	}
+	for _, c := range []byte(key) {
+		if c < 0x21 || c > 0x7e {
+			return fmt.Errorf("%w: only printable ASCII without spaces is allowed", ErrInvalidIdempotencyKey)
+		}
+	}
+	return nil
+}
+
+// requestHash fingerprints the decoded request, so formatting and field
+// order of the original body do not matter.
+func requestHash(req models.LinkRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestCreateTaskIdempotent(t *testing.T) {
+	t.Parallel()
+
+	req := models.LinkRequest{Links: []string{"https://example.com"}}
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		between  func(svc *Service, id int)
+		key      string
+		replayed bool
+		err      error
+	}{
+		{name: "replay", ttl: time.Hour, key: "k1", replayed: true},
+		{name: "expired", ttl: time.Millisecond, between: func(*Service, int) { time.Sleep(5 * time.Millisecond) }, key: "k1"},
+		{name: "task deleted", ttl: time.Hour, between: func(svc *Service, id int) { _ = svc.DeleteTask(context.Background(), id) }, key: "k1"},
+		{name: "other key", ttl: time.Hour, key: "k2"},
+		{name: "invalid key", ttl: time.Hour, key: "with space", err: ErrInvalidIdempotencyKey},
+		{name: "too long", ttl: time.Hour, key: strings.Repeat("k", maxIdempotencyKeyLen+1), err: ErrInvalidIdempotencyKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			svc := NewService(repository.NewMemoryRepo(), nil, 5, WithIdempotencyTTL(tt.ttl))
+			first, _, err := svc.CreateTaskIdempotent(context.Background(), "k1", req)
+			if err != nil {
+				t.Fatalf("first request: %v", err)
+			}
+			if tt.between != nil {
+				tt.between(svc, first)
+			}
+
+			id, replayed, err := svc.CreateTaskIdempotent(context.Background(), tt.key, req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if replayed != tt.replayed || (id == first) != tt.replayed {
+				t.Fatalf("replayed=%v id=%d, first id %d", replayed, id, first)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/cron"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var (
+	ErrMonitorNotFound = errors.New("monitor not found")
+	ErrInvalidMonitor  = errors.New("invalid monitor")
+)
+
+const (
+	minMonitorInterval = 30 * time.Second
+	maxCronJitter      = time.Hour
+	// maxMonitorWait bounds the sleep of RunMonitors so clock jumps are
+	// noticed.
+	maxMonitorWait = time.Minute
+)
+
+func (s *Service) CreateMonitor(req models.MonitorRequest) (models.Monitor, error) {
+	if err := s.validateMonitor(&req); err != nil {
+		return models.Monitor{}, err
+	}
+
+	s.monMu.Lock()
+	defer s.monMu.Unlock()
+
+	now := clock.Now()
+	s.nextMonitorID++
+	monitor := &models.Monitor{
+		ID:             s.nextMonitorID,
+		MonitorRequest: req,
+		CreatedAt:      now,
+	}
+	scheduleMonitor(monitor, now)
+	s.monitors[monitor.ID] = monitor
+	s.repo.SaveMonitor(monitor)
+	s.wakeMonitors()
+	return *monitor, nil
+}
+
+// UpdateMonitor replaces the definition of a monitor; its run history is
+// kept.
+func (s *Service) UpdateMonitor(id int, req models.MonitorRequest) (models.Monitor, error) {
+	if err := s.validateMonitor(&req); err != nil {
+		return models.Monitor{}, err
+	}
+
+	s.monMu.Lock()
+	defer s.monMu.Unlock()
+
+	monitor, ok := s.monitors[id]
+	if !ok {
+		return models.Monitor{}, ErrMonitorNotFound
+	}
+	monitor.MonitorRequest = req
+	scheduleMonitor(monitor, clock.Now())
+	s.repo.SaveMonitor(monitor)
+	s.wakeMonitors()
+	return *monitor, nil
+}
+
+// DeleteMonitor stops future runs; tasks of past runs stay available.
+func (s *Service) DeleteMonitor(id int) error {
+	s.monMu.Lock()
+	defer s.monMu.Unlock()
+
+	if _, ok := s.monitors[id]; !ok {
+		return ErrMonitorNotFound
+	}
+	delete(s.monitors, id)
+	s.repo.DeleteMonitor(id)
+	return nil
+}
+
+func (s *Service) GetMonitor(id int) (models.Monitor, error) {
+	s.monMu.Lock()
+	defer s.monMu.Unlock()
+
+	monitor, ok := s.monitors[id]
+	if !ok {
+		return models.Monitor{}, ErrMonitorNotFound
+	}
+	return *monitor, nil
+}
+
+func (s *Service) ListMonitors() []models.Monitor {
+	s.monMu.Lock()
+	defer s.monMu.Unlock()
+
+	monitors := make([]models.Monitor, 0, len(s.monitors))
+	for _, monitor := range s.monitors {
+		monitors = append(monitors, *monitor)
+	}
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i].ID < monitors[j].ID })
+	return monitors
+}
+
+// MonitorRuns returns the tasks started by a monitor, newest first.
+func (s *Service) MonitorRuns(id int) ([]*models.Task, error) {
+	if _, err := s.GetMonitor(id); err != nil {
+		return nil, err
+	}
+	return s.repo.MonitorRuns(id), nil
+}
+
+// RunMonitors starts monitor runs when they are due until ctx is done. Runs
+// go through CreateTask like any other task, so a full queue skips a run
+// instead of blocking the loop. Runs missed while the server was down are
+// collapsed into one.
+func (s *Service) RunMonitors(ctx context.Context) {
+	for {
+		timer := time.NewTimer(s.runDueMonitors(ctx))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-s.monitorWake:
+			timer.Stop()
+		}
+	}
+}
+
+func (s *Service) runDueMonitors(ctx context.Context) time.Duration {
+	now := clock.Now()
+	wait := maxMonitorWait
+
+	s.monMu.Lock()
+	var due []models.Monitor
+	for _, monitor := range s.monitors {
+		if monitor.NextRun == nil {
+			continue
+		}
+		if !monitor.NextRun.After(now) {
+			due = append(due, *monitor)
+			scheduleMonitor(monitor, now)
+		}
+		if monitor.NextRun != nil {
+			wait = min(wait, monitor.NextRun.Sub(now))
+		}
+	}
+	s.monMu.Unlock()
+
+	for _, monitor := range due {
+		s.startMonitorRun(ctx, monitor)
+	}
+	return max(wait, 0)
+}
+
+func (s *Service) startMonitorRun(ctx context.Context, monitor models.Monitor) {
+	if status, ok := s.repo.Status(monitor.LastTaskID); ok && !isFinished(status) {
+		s.recordMonitorRun(monitor.ID, 0, fmt.Errorf("run skipped: previous run #%d is still active", monitor.LastTaskID))
+		return
+	}
+
+	id, err := s.createTask(ctx, monitor.LinkRequest, monitor.ID, nil)
+	if err != nil {
+		log.Printf("monitor %d (%s): %v", monitor.ID, monitor.Name, err)
+	}
+	s.recordMonitorRun(monitor.ID, id, err)
+}
+
+func (s *Service) recordMonitorRun(id, taskID int, err error) {
+	s.monMu.Lock()
+	defer s.monMu.Unlock()
+
+	monitor, ok := s.monitors[id]
+	if !ok {
+		return
+	}
+	now := clock.Now()
+	monitor.LastRun = &now
+	monitor.LastError = ""
+	if taskID != 0 {
+		monitor.LastTaskID = taskID
+	}
+	if err != nil {
+		monitor.LastError = err.Error()
+	}
+	s.repo.SaveMonitor(monitor)
+}
+
+// loadMonitors takes the stored monitors; the repository hands out copies,
+// so the service owns them and the persister never sees its writes.
+func (s *Service) loadMonitors() {
+	for _, monitor := range s.repo.Monitors() {
+		s.monitors[monitor.ID] = monitor
+	}
+	s.nextMonitorID = s.repo.MaxMonitorID()
+}
+
+func (s *Service) wakeMonitors() {
+	select {
+	case s.monitorWake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) validateMonitor(req *models.MonitorRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidMonitor)
+	}
+	if len(req.Links) == 0 && req.Sitemap == "" {
+		return ErrEmptyLinks
+	}
+	if req.Deadline != nil {
+		return fmt.Errorf("%w: use max_duration_seconds to limit runs", ErrInvalidMonitor)
+	}
+	if req.MaxDurationSeconds < 0 {
+		return fmt.Errorf("%w: max_duration_seconds must be positive", ErrInvalidDeadline)
+	}
+	if _, ok := priorityLevels[req.Priority]; req.Priority != "" && !ok {
+		return fmt.Errorf("%w: %q, use low, normal, high or urgent", ErrInvalidPriority, req.Priority)
+	}
+	if req.Crawl != nil && s.fetcher == nil {
+		return fmt.Errorf("%w: crawling is not configured", ErrInvalidCrawl)
+	}
+	if err := validateOptions(req.CheckOptions); err != nil {
+		return err
+	}
+	if err := s.checkSecrets(req.CheckOptions, req.Locations); err != nil {
+		return err
+	}
+	if _, err := s.newCallback(req.LinkRequest); err != nil {
+		return err
+	}
+
+	jitter := time.Duration(req.JitterSeconds) * time.Second
+	switch {
+	case (req.IntervalSeconds == 0) == (req.Cron == ""):
+		return fmt.Errorf("%w: set either interval_seconds or cron", ErrInvalidMonitor)
+	case req.JitterSeconds < 0:
+		return fmt.Errorf("%w: jitter_seconds must be positive", ErrInvalidMonitor)
+	case req.Cron != "":
+		schedule, err := cron.Parse(req.Cron)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMonitor, err)
+		}
+		if schedule.Next(clock.Now()).IsZero() {
+			return fmt.Errorf("%w: cron %q never fires", ErrInvalidMonitor, req.Cron)
+		}
+		if jitter > maxCronJitter {
+			return fmt.Errorf("%w: jitter_seconds must not exceed %d", ErrInvalidMonitor, int(maxCronJitter/time.Second))
+		}
+	default:
+		interval := time.Duration(req.IntervalSeconds) * time.Second
+		if interval < minMonitorInterval {
+			return fmt.Errorf("%w: interval_seconds must be at least %d", ErrInvalidMonitor, int(minMonitorInterval/time.Second))
+		}
+		if jitter >= interval {
+			return fmt.Errorf("%w: jitter_seconds must be shorter than the interval", ErrInvalidMonitor)
+		}
+	}
+	return nil
+}
+
+// scheduleMonitor sets the next run after now; paused monitors have none.
+func scheduleMonitor(monitor *models.Monitor, now time.Time) {
+	monitor.NextRun = nil
+	if monitor.Paused {
+		return
+	}
+
+	var next time.Time
+	if monitor.Cron != "" {
+		schedule, err := cron.Parse(monitor.Cron)
+		if err != nil {
+			return
+		}
+		next = schedule.Next(now)
+	} else {
+		next = now.Add(time.Duration(monitor.IntervalSeconds) * time.Second)
+	}
+	if next.IsZero() {
+		return
+	}
+	if monitor.JitterSeconds > 0 {
+		next = next.Add(rand.N(time.Duration(monitor.JitterSeconds) * time.Second))
+	}
+	monitor.NextRun = &next
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestValidateMonitor(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 5)
+	links := models.LinkRequest{Links: []string{"example.com"}}
+	deadline := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		req  models.MonitorRequest
+		want error
+	}{
+		{name: "interval", req: models.MonitorRequest{Name: "site", IntervalSeconds: 300, JitterSeconds: 30, LinkRequest: links}},
+		{name: "cron", req: models.MonitorRequest{Name: "site", Cron: "*/5 * * * *", LinkRequest: links}},
+		{name: "missing name", req: models.MonitorRequest{IntervalSeconds: 300, LinkRequest: links}, want: ErrInvalidMonitor},
+		{name: "no schedule", req: models.MonitorRequest{Name: "site", LinkRequest: links}, want: ErrInvalidMonitor},
+		{name: "both schedules", req: models.MonitorRequest{Name: "site", IntervalSeconds: 300, Cron: "@hourly", LinkRequest: links}, want: ErrInvalidMonitor},
+		{name: "interval too short", req: models.MonitorRequest{Name: "site", IntervalSeconds: 5, LinkRequest: links}, want: ErrInvalidMonitor},
+		{name: "jitter too long", req: models.MonitorRequest{Name: "site", IntervalSeconds: 60, JitterSeconds: 60, LinkRequest: links}, want: ErrInvalidMonitor},
+		{name: "bad cron", req: models.MonitorRequest{Name: "site", Cron: "every day", LinkRequest: links}, want: ErrInvalidMonitor},
+		{name: "no links", req: models.MonitorRequest{Name: "site", IntervalSeconds: 300}, want: ErrEmptyLinks},
+		{name: "absolute deadline", req: models.MonitorRequest{Name: "site", IntervalSeconds: 300, LinkRequest: models.LinkRequest{Links: []string{"example.com"}, Deadline: &deadline}}, want: ErrInvalidMonitor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := svc.validateMonitor(&tt.req)
+			if tt.want == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRunMonitors_StartsRunsAndPersists(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo, err := repository.NewPersistentRepo(path)
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	svc := NewService(repo, fakeSite{}, 5)
+	monitor, err := svc.CreateMonitor(models.MonitorRequest{
+		Name:            "homepage",
+		IntervalSeconds: 300,
+		LinkRequest:     models.LinkRequest{Links: []string{"example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("create monitor: %v", err)
+	}
+	if monitor.NextRun == nil || monitor.NextRun.Sub(monitor.CreatedAt) != 5*time.Minute {
+		t.Fatalf("unexpected next run: %v", monitor.NextRun)
+	}
+
+	makeDue := func() {
+		svc.monMu.Lock()
+		past := clock.Now().Add(-time.Second)
+		svc.monitors[monitor.ID].NextRun = &past
+		svc.monMu.Unlock()
+	}
+
+	makeDue()
+	svc.runDueMonitors(context.Background())
+	runs, _ := svc.MonitorRuns(monitor.ID)
+	if len(runs) != 1 || runs[0].MonitorID != monitor.ID || svc.QueuePosition(runs[0].ID) != 1 {
+		t.Fatalf("monitor run not queued: %+v", runs)
+	}
+
+	makeDue()
+	svc.runDueMonitors(context.Background())
+	got, _ := svc.GetMonitor(monitor.ID)
+	if runs, _ := svc.MonitorRuns(monitor.ID); len(runs) != 1 || !strings.Contains(got.LastError, "still active") {
+		t.Fatalf("overlapping run not skipped: %d runs, last error %q", len(runs), got.LastError)
+	}
+	if got.NextRun == nil || !got.NextRun.After(clock.Now()) {
+		t.Fatalf("next run not rescheduled: %v", got.NextRun)
+	}
+
+	reopened, err := repository.NewPersistentRepo(path)
+	if err != nil {
+		t.Fatalf("reopen repo: %v", err)
+	}
+	restarted := NewService(reopened, fakeSite{}, 5)
+	loaded, err := restarted.GetMonitor(monitor.ID)
+	if err != nil || loaded.Name != "homepage" || loaded.LastTaskID != runs[0].ID {
+		t.Fatalf("monitor not restored: %+v, %v", loaded, err)
+	}
+	next, err := restarted.CreateMonitor(models.MonitorRequest{Name: "docs", Cron: "@daily", LinkRequest: models.LinkRequest{Links: []string{"docs.example.com"}}})
+	if err != nil || next.ID != monitor.ID+1 {
+		t.Fatalf("monitor ids reused: %+v, %v", next, err)
+	}
+
+	if err := restarted.DeleteMonitor(monitor.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := restarted.GetMonitor(monitor.ID); !errors.Is(err, ErrMonitorNotFound) {
+		t.Fatalf("deleted monitor still present: %v", err)
+	}
+}
+
+func TestUpdateMonitor_DoesNotRaceWithPersistence(t *testing.T) {
+	t.Parallel()
+
+	repo, err := repository.NewPersistentRepo(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	svc := NewService(repo, fakeSite{}, 50)
+	req := models.MonitorRequest{Name: "homepage", IntervalSeconds: 300, LinkRequest: models.LinkRequest{Links: []string{"example.com"}}}
+	monitor, err := svc.CreateMonitor(req)
+	if err != nil {
+		t.Fatalf("create monitor: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"example.org"}})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		req.IntervalSeconds = 300 + i
+		if _, err := svc.UpdateMonitor(monitor.ID, req); err != nil {
+			t.Fatalf("update monitor: %v", err)
+		}
+	}
+	<-done
+
+	if stored := repo.Monitors(); len(stored) != 1 || stored[0].IntervalSeconds != 319 {
+		t.Fatalf("unexpected stored monitor: %+v", stored)
+	}
+}
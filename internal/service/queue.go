@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var (
+	ErrQueueFull    = errors.New("task queue is full")
+	ErrShuttingDown = errors.New("service is shutting down")
+)
+
+const (
+	defaultQueueAging = time.Minute
+	defaultRetryAfter = 5 * time.Second
+	maxRetryAfter     = time.Minute
+)
+
+var priorityLevels = map[string]int{
+	models.PriorityLow:    0,
+	models.PriorityNormal: 1,
+	models.PriorityHigh:   2,
+	models.PriorityUrgent: 3,
+}
+
+// QueueFullError rejects a task when both the queue and the spool are full.
+type QueueFullError struct {
+	Depth      int
+	Capacity   int
+	RetryAfter time.Duration
+}
+
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("task queue is full (%d/%d), retry in %v", e.Depth, e.Capacity, e.RetryAfter)
+}
+
+func (e *QueueFullError) Unwrap() error {
+	return ErrQueueFull
+}
+
+func WithQueueAging(step time.Duration) Option {
+	return func(s *Service) {
+		if step > 0 {
+			s.queue.aging = step
+		}
+	}
+}
+
+// WithSpool accepts up to limit tasks beyond the queue capacity. Spooled
+// tasks are persisted as pending like any other task and move into the queue
+// as it drains, the same priority and aging rules choosing which goes first.
+func WithSpool(limit int) Option {
+	return func(s *Service) {
+		if limit > 0 {
+			s.queue.spoolLimit = limit
+		}
+	}
+}
+
+type queuedTask struct {
+	task     *models.Task
+	level    int
+	seq      uint64
+	enqueued time.Time
+}
+
+// taskQueue is a bounded priority queue. Tasks of the same level leave in
+// FIFO order; every aging step spent waiting raises a task by one level so
+// low-priority work cannot be postponed forever. Tasks beyond the capacity
+// wait in the spool, if one is configured.
+type taskQueue struct {
+	mu         sync.Mutex
+	items      []*queuedTask
+	spool      []*queuedTask
+	capacity   int
+	spoolLimit int
+	aging      time.Duration
+	seq        uint64
+	closed     bool
+	wake       chan struct{}
+	lastPop    time.Time
+	popGap     latencyStats
+}
+
+func newTaskQueue(capacity int) *taskQueue {
+	return &taskQueue{
+		capacity: capacity,
+		aging:    defaultQueueAging,
+		wake:     make(chan struct{}),
+	}
+}
+
+// offer admits a task without waiting. persist runs only once the task has
+// a place, so rejected tasks are never stored.
+func (q *taskQueue) offer(task *models.Task, persist func()) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.admitLocked(); err != nil {
+		return err
+	}
+	persist()
+	q.addLocked(task)
+	return nil
+}
+
+// admit reports the error offer would return right now.
+func (q *taskQueue) admit() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.admitLocked()
+}
+
+func (q *taskQueue) admitLocked() error {
+	if q.closed {
+		return ErrShuttingDown
+	}
+	if len(q.items) >= q.capacity && len(q.spool) >= q.spoolLimit {
+		return &QueueFullError{
+			Depth:      len(q.items) + len(q.spool),
+			Capacity:   q.capacity + q.spoolLimit,
+			RetryAfter: q.retryAfterLocked(),
+		}
+	}
+	return nil
+}
+
+// restore queues a task loaded from storage; restored tasks are always
+// accepted, overflowing into the spool.
+func (q *taskQueue) restore(task *models.Task) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.addLocked(task)
+}
+
+func (q *taskQueue) addLocked(task *models.Task) {
+	q.seq++
+	item := &queuedTask{
+		task:     task,
+		level:    priorityLevel(task.Priority),
+		seq:      q.seq,
+		enqueued: time.Now(),
+	}
+	if len(q.items) < q.capacity && len(q.spool) == 0 {
+		q.items = append(q.items, item)
+	} else {
+		q.spool = append(q.spool, item)
+	}
+	q.signalLocked()
+}
+
+// pop blocks until a task is available. It returns false once the queue is
+// closed and drained.
+func (q *taskQueue) pop(ctx context.Context) (*models.Task, bool) {
+	q.mu.Lock()
+	for len(q.items) == 0 {
+		if q.closed {
+			q.mu.Unlock()
+			return nil, false
+		}
+		wake := q.wake
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-wake:
+		}
+		q.mu.Lock()
+	}
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	q.sortLocked(now)
+	item := q.items[0]
+	q.items = q.items[1:]
+	q.promoteLocked(now)
+	if !q.lastPop.IsZero() {
+		q.popGap.observe(now.Sub(q.lastPop))
+	}
+	q.lastPop = now
+	q.signalLocked()
+	return item.task, true
+}
+
+// position returns the 1-based place of a waiting task, or 0. Spooled tasks
+// come after the whole queue.
+func (q *taskQueue) position(taskID int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.sortLocked(time.Now())
+	for i, item := range q.items {
+		if item.task.ID == taskID {
+			return i + 1
+		}
+	}
+	for i, item := range q.spool {
+		if item.task.ID == taskID {
+			return len(q.items) + i + 1
+		}
+	}
+	return 0
+}
+
+// remove drops a waiting task and reports whether it was queued.
+func (q *taskQueue) remove(taskID int) (*models.Task, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, item := range q.items {
+		if item.task.ID == taskID {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			q.promoteLocked(time.Now())
+			q.signalLocked()
+			return item.task, true
+		}
+	}
+	for i, item := range q.spool {
+		if item.task.ID == taskID {
+			q.spool = append(q.spool[:i], q.spool[i+1:]...)
+			return item.task, true
+		}
+	}
+	return nil, false
+}
+
+// links counts the links of waiting tasks.
+func (q *taskQueue) links() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for _, item := range q.items {
+		n += len(item.task.Results)
+	}
+	for _, item := range q.spool {
+		n += len(item.task.Results)
+	}
+	return n
+}
+
+func (q *taskQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.signalLocked()
+}
+
+// retryAfterLocked estimates when a slot frees up from the recent pace of
+// the workers taking tasks.
+func (q *taskQueue) retryAfterLocked() time.Duration {
+	gap := q.popGap.average()
+	if gap <= 0 {
+		return defaultRetryAfter
+	}
+	return min(max(gap, time.Second), maxRetryAfter)
+}
+
+func (q *taskQueue) sortLocked(now time.Time) {
+	q.sortItems(q.items, now)
+	q.sortItems(q.spool, now)
+}
+
+// promoteLocked moves the most urgent spooled task into the queue.
+func (q *taskQueue) promoteLocked(now time.Time) {
+	if len(q.spool) == 0 {
+		return
+	}
+	q.sortItems(q.spool, now)
+	q.items = append(q.items, q.spool[0])
+	q.spool = q.spool[1:]
+}
+
+func (q *taskQueue) sortItems(items []*queuedTask, now time.Time) {
+	sort.SliceStable(items, func(i, j int) bool {
+		pi, pj := q.effectiveLevel(items[i], now), q.effectiveLevel(items[j], now)
+		if pi != pj {
+			return pi > pj
+		}
+		return items[i].seq < items[j].seq
+	})
+}
+
+func (q *taskQueue) effectiveLevel(item *queuedTask, now time.Time) int {
+	level := item.level + int(now.Sub(item.enqueued)/q.aging)
+	return min(level, priorityLevels[models.PriorityUrgent])
+}
+
+func (q *taskQueue) signalLocked() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+func priorityLevel(priority string) int {
+	if level, ok := priorityLevels[priority]; ok {
+		return level
+	}
+	return priorityLevels[models.PriorityNormal]
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestTaskQueue_PriorityOrderAndAging(t *testing.T) {
+	t.Parallel()
+
+	q := newTaskQueue(10)
+	push := func(id int, priority string) {
+		if err := q.offer(&models.Task{ID: id, Priority: priority}, func() {}); err != nil {
+			t.Fatalf("push %d: %v", id, err)
+		}
+	}
+	push(1, models.PriorityLow)
+	push(2, "")
+	push(3, models.PriorityUrgent)
+	push(4, models.PriorityNormal)
+	push(5, models.PriorityHigh)
+
+	if pos := q.position(4); pos != 4 {
+		t.Fatalf("position of task 4 = %d, want 4", pos)
+	}
+
+	var order []int
+	for i := 0; i < 5; i++ {
+		task, _ := q.pop(context.Background())
+		order = append(order, task.ID)
+	}
+	want := []int{3, 5, 2, 4, 1}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("pop order = %v, want %v", order, want)
+		}
+	}
+
+	q.aging = 20 * time.Millisecond
+	push(6, models.PriorityLow)
+	time.Sleep(50 * time.Millisecond)
+	push(7, models.PriorityHigh)
+	if task, _ := q.pop(context.Background()); task.ID != 6 {
+		t.Fatalf("aged low-priority task should run first, got %d", task.ID)
+	}
+}
+
+func TestTaskQueue_RejectsWhenFullAndCloses(t *testing.T) {
+	t.Parallel()
+
+	q := newTaskQueue(1)
+	_ = q.offer(&models.Task{ID: 1}, func() {})
+
+	persisted := false
+	err := q.offer(&models.Task{ID: 2}, func() { persisted = true })
+	var full *QueueFullError
+	if !errors.As(err, &full) || !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected QueueFullError, got %v", err)
+	}
+	if persisted || full.Depth != 1 || full.Capacity != 1 || full.RetryAfter <= 0 {
+		t.Fatalf("unexpected rejection: persisted %v, %+v", persisted, full)
+	}
+
+	q.close()
+	if err := q.offer(&models.Task{ID: 3}, func() {}); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("expected ErrShuttingDown, got %v", err)
+	}
+	if task, ok := q.pop(context.Background()); !ok || task.ID != 1 {
+		t.Fatalf("closed queue must still be drained")
+	}
+	if _, ok := q.pop(context.Background()); ok {
+		t.Fatalf("drained closed queue must report false")
+	}
+}
+
+func TestTaskQueue_Spool(t *testing.T) {
+	t.Parallel()
+
+	q := newTaskQueue(1)
+	q.spoolLimit = 2
+	for id := 1; id <= 3; id++ {
+		if err := q.offer(&models.Task{ID: id}, func() {}); err != nil {
+			t.Fatalf("offer %d: %v", id, err)
+		}
+	}
+	if err := q.offer(&models.Task{ID: 4}, func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("spool limit not enforced: %v", err)
+	}
+	if q.position(3) != 3 {
+		t.Fatalf("position of spooled task = %d, want 3", q.position(3))
+	}
+
+	for want := 1; want <= 3; want++ {
+		task, _ := q.pop(context.Background())
+		if task.ID != want {
+			t.Fatalf("pop = %d, want %d", task.ID, want)
+		}
+	}
+}
+
+func TestTaskQueue_SpoolPromotesByPriority(t *testing.T) {
+	t.Parallel()
+
+	q := newTaskQueue(1)
+	q.spoolLimit = 3
+	tasks := []*models.Task{
+		{ID: 1, Priority: models.PriorityNormal},
+		{ID: 2, Priority: models.PriorityLow},
+		{ID: 3, Priority: models.PriorityNormal},
+		{ID: 4, Priority: models.PriorityUrgent},
+	}
+	for _, task := range tasks {
+		if err := q.offer(task, func() {}); err != nil {
+			t.Fatalf("offer %d: %v", task.ID, err)
+		}
+	}
+	if q.position(4) != 2 {
+		t.Fatalf("position of urgent spooled task = %d, want 2", q.position(4))
+	}
+
+	for _, want := range []int{1, 4, 3, 2} {
+		task, _ := q.pop(context.Background())
+		if task.ID != want {
+			t.Fatalf("pop = %d, want %d", task.ID, want)
+		}
+	}
+}
+
+func TestNewService_RestoresOverflowIntoSpool(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewMemoryRepo()
+	for id := 1; id <= 3; id++ {
+		repo.Save(&models.Task{ID: id, Status: models.StatusPending})
+	}
+	svc := NewService(repo, fakeSite{}, 1)
+	for id := 1; id <= 3; id++ {
+		if svc.QueuePosition(id) != id {
+			t.Fatalf("task %d at position %d", id, svc.QueuePosition(id))
+		}
+	}
+	if _, err := svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"example.com"}}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if repo.MaxID() != 3 {
+		t.Fatalf("rejected task was stored")
+	}
+}
+
+func TestCreateTask_Priority(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 5)
+	if _, err := svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"example.com"}, Priority: "asap"}); !errors.Is(err, ErrInvalidPriority) {
+		t.Fatalf("expected ErrInvalidPriority, got %v", err)
+	}
+
+	first, _ := svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"example.com"}})
+	urgent, _ := svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"example.org"}, Priority: models.PriorityUrgent})
+	if svc.QueuePosition(urgent) != 1 || svc.QueuePosition(first) != 2 {
+		t.Fatalf("unexpected queue positions: urgent %d, normal %d", svc.QueuePosition(urgent), svc.QueuePosition(first))
+	}
+}
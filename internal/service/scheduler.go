@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// taskRun tracks a task whose links are being checked. Several workers fill
+// in results of the same task, and crawling may grow them, so the mutex
+// guards every change to the task; it is saved under the mutex too. Readers
+// only see the copies kept by the repository. Cancelling the run or reaching
+// the task deadline aborts its in-flight checks through ctx.
+type taskRun struct {
+	task      *models.Task
+	crawl     *crawlState
+	remote    int
+	pool      *WorkerPool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+	finished  chan struct{}
+
+	mu      sync.Mutex
+	next    int
+	pending int
+	done    bool
+}
+
+func (r *taskRun) expired() bool {
+	return errors.Is(r.ctx.Err(), context.DeadlineExceeded)
+}
+
+type linkJob struct {
+	run   *taskRun
+	index int
+	url   string
+}
+
+// take hands out the next link checked by the local pool.
+func (r *taskRun) take() (linkJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancelled.Load() || r.ctx.Err() != nil {
+		return linkJob{}, false
+	}
+	for r.next < len(r.task.Results) {
+		i := r.next
+		r.next++
+		if isRemote(r.task.Results[i]) {
+			continue
+		}
+		r.pending++
+		return linkJob{run: r, index: i, url: r.task.Results[i].URL}, true
+	}
+	return linkJob{}, false
+}
+
+// finishLocked reports whether the last local check or remote result just
+// finished. A cancelled or expired run is finished as soon as its in-flight
+// local checks return; remote results still out are abandoned.
+func (r *taskRun) finishLocked() bool {
+	if r.done || r.pending > 0 {
+		return false
+	}
+	if r.cancelled.Load() || r.expired() {
+		r.done = true
+		return true
+	}
+	if r.remote > 0 {
+		return false
+	}
+	for i := r.next; i < len(r.task.Results); i++ {
+		if !isRemote(r.task.Results[i]) {
+			return false
+		}
+	}
+	r.done = true
+	return true
+}
+
+// scheduler hands out single link checks from the active tasks in
+// round-robin order, so a huge task gets the same share of workers as a
+// small one. At most limit tasks are active; the rest wait in the queue.
+type scheduler struct {
+	mu     sync.Mutex
+	runs   []*taskRun
+	cursor int
+	limit  int
+	closed bool
+	wake   chan struct{}
+}
+
+func newScheduler(limit int) *scheduler {
+	return &scheduler{limit: limit, wake: make(chan struct{})}
+}
+
+// add blocks while the active set is full.
+func (s *scheduler) add(ctx context.Context, run *taskRun) bool {
+	s.mu.Lock()
+	for len(s.runs) >= s.limit {
+		wake := s.wake
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-wake:
+		}
+		s.mu.Lock()
+	}
+	// A run cancelled or expired while waiting here is already complete.
+	run.mu.Lock()
+	done := run.done
+	run.mu.Unlock()
+	if !done {
+		s.runs = append(s.runs, run)
+	}
+	s.signalLocked()
+	s.mu.Unlock()
+	return true
+}
+
+// next blocks until a link is available. It returns false once the
+// scheduler is closed and every active task has finished.
+func (s *scheduler) next(ctx context.Context) (linkJob, bool) {
+	s.mu.Lock()
+	for {
+		// A worker removed by Resize must not pick up more work.
+		if ctx.Err() != nil {
+			s.mu.Unlock()
+			return linkJob{}, false
+		}
+		for k := 0; k < len(s.runs); k++ {
+			pos := (s.cursor + k) % len(s.runs)
+			if job, ok := s.runs[pos].take(); ok {
+				s.cursor = (pos + 1) % len(s.runs)
+				s.mu.Unlock()
+				return job, true
+			}
+		}
+		if s.closed && len(s.runs) == 0 {
+			s.mu.Unlock()
+			return linkJob{}, false
+		}
+
+		wake := s.wake
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return linkJob{}, false
+		case <-wake:
+		}
+		s.mu.Lock()
+	}
+}
+
+func (s *scheduler) remove(run *taskRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range s.runs {
+		if r == run {
+			s.runs = append(s.runs[:i], s.runs[i+1:]...)
+			if s.cursor > i {
+				s.cursor--
+			}
+			break
+		}
+	}
+	if len(s.runs) > 0 {
+		s.cursor %= len(s.runs)
+	} else {
+		s.cursor = 0
+	}
+	s.signalLocked()
+}
+
+func (s *scheduler) setLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.signalLocked()
+}
+
+// backlog counts links of active tasks that were not handed out yet.
+func (s *scheduler) backlog() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, run := range s.runs {
+		run.mu.Lock()
+		if !run.cancelled.Load() && run.ctx.Err() == nil {
+			n += len(run.task.Results) - run.next
+		}
+		run.mu.Unlock()
+	}
+	return n
+}
+
+// signal wakes waiting workers after a running task gained new links.
+func (s *scheduler) signal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signalLocked()
+}
+
+func (s *scheduler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.signalLocked()
+}
+
+func (s *scheduler) signalLocked() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+type recordingChecker struct {
+	mu       sync.Mutex
+	order    []string
+	inFlight int
+	peak     int
+	delay    time.Duration
+}
+
+func (c *recordingChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	c.mu.Lock()
+	c.order = append(c.order, url)
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return models.LinkStatus{URL: url, Status: models.StatusAvailable}
+}
+
+func linkList(host string, n int) []string {
+	links := make([]string, n)
+	for i := range links {
+		links[i] = fmt.Sprintf("https://%s/page/%d", host, i)
+	}
+	return links
+}
+
+func TestWorkerPool_SmallTaskIsNotStarved(t *testing.T) {
+	t.Parallel()
+
+	checker := &recordingChecker{}
+	svc := NewService(repository.NewMemoryRepo(), checker, 5)
+	big, _ := svc.CreateTask(context.Background(), models.LinkRequest{Links: linkList("big.example", 50)})
+	small, _ := svc.CreateTask(context.Background(), models.LinkRequest{Links: linkList("small.example", 2)})
+
+	pool := NewWorkerPool(svc, 1)
+	pool.Start(context.Background())
+	svc.CloseQueue()
+	pool.Wait()
+
+	last := 0
+	for i, url := range checker.order {
+		if strings.Contains(url, "small.example") {
+			last = i
+		}
+	}
+	if last > 5 {
+		t.Fatalf("small task finished after %d checks: %v", last+1, checker.order[:last+1])
+	}
+
+	for _, id := range []int{big, small} {
+		task, _ := svc.GetTask(id)
+		if task.Status != models.StatusDone {
+			t.Fatalf("task %d not done: %s", id, task.Status)
+		}
+		for _, res := range task.Results {
+			if res.Status != models.StatusAvailable {
+				t.Fatalf("task %d: unchecked result %+v", id, res)
+			}
+		}
+	}
+}
+
+func TestWorkerPool_ChecksLinksOfOneTaskInParallel(t *testing.T) {
+	t.Parallel()
+
+	checker := &recordingChecker{delay: 20 * time.Millisecond}
+	svc := NewService(repository.NewMemoryRepo(), checker, 1)
+	id, _ := svc.CreateTask(context.Background(), models.LinkRequest{Links: linkList("example.com", 12)})
+
+	pool := NewWorkerPool(svc, 4)
+	pool.Start(context.Background())
+	svc.CloseQueue()
+	pool.Wait()
+
+	if checker.peak < 2 {
+		t.Fatalf("links of a single task were checked sequentially")
+	}
+	task, _ := svc.GetTask(id)
+	if task.Status != models.StatusDone || len(checker.order) != 12 {
+		t.Fatalf("unexpected task state: %s after %d checks", task.Status, len(checker.order))
+	}
+}
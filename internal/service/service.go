@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/whiterage/14-11-2025/internal/repository"
@@ -14,84 +17,215 @@ import (
 )
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
-	ErrEmptyLinks   = errors.New("empty links payload")
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrEmptyLinks      = errors.New("empty links payload")
+	ErrInvalidAuth     = errors.New("invalid auth options")
+	ErrInvalidCrawl    = errors.New("invalid crawl options")
+	ErrInvalidPriority = errors.New("invalid priority")
+	ErrTaskFinished    = errors.New("task already finished")
 )
 
 type Checker interface {
-	Check(ctx context.Context, url string) models.LinkStatus
+	Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus
+}
+
+type Fetcher interface {
+	Fetch(ctx context.Context, url string, opts models.CheckOptions) (*models.Page, error)
+}
+
+// SitemapFetcher is implemented by fetchers that accept documents of
+// sitemap size, which may be far larger than a page.
+type SitemapFetcher interface {
+	FetchSitemap(ctx context.Context, url string, opts models.CheckOptions) (*models.Page, error)
+}
+
+type Option func(*Service)
+
+func WithFetcher(fetcher Fetcher) Option {
+	return func(s *Service) {
+		s.fetcher = fetcher
+	}
+}
+
+// WithSecrets lets the service check that the secrets a task references
+// exist when it is created.
+func WithSecrets(secrets SecretLookup) Option {
+	return func(s *Service) {
+		s.secrets = secrets
+	}
 }
 
 type Service struct {
-	repo    *repository.MemoryRepo
-	queue   chan *models.Task
-	checker Checker
-	mu      sync.Mutex
-	nextID  int
-	closed  atomic.Bool
-	closeW  sync.Once
+	repo         *repository.MemoryRepo
+	queue        *taskQueue
+	checker      Checker
+	fetcher      Fetcher
+	agents       *AgentHub
+	webhooks     *WebhookOutbox
+	secrets      SecretLookup
+	sitemapLimit int
+	mu           sync.Mutex
+	nextID       int
+	runsMu       sync.Mutex
+	runs         map[int]*taskRun
+
+	monMu         sync.Mutex
+	monitors      map[int]*models.Monitor
+	nextMonitorID int
+	monitorWake   chan struct{}
+
+	idemMu         sync.Mutex
+	idemInFlight   map[string]struct{}
+	idempotencyTTL time.Duration
 }
 
-func NewService(repo *repository.MemoryRepo, checker Checker, queueSize int) *Service {
+func NewService(repo *repository.MemoryRepo, checker Checker, queueSize int, opts ...Option) *Service {
 	if queueSize <= 0 {
 		queueSize = 10
 	}
 
 	pending := repo.PendingTasks()
-	if len(pending) > queueSize {
-		queueSize = len(pending) + 5
-	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
 
 	s := &Service{
-		repo:    repo,
-		queue:   make(chan *models.Task, queueSize),
-		checker: checker,
-		nextID:  repo.MaxID() + 1,
+		repo:         repo,
+		queue:        newTaskQueue(queueSize),
+		checker:      checker,
+		sitemapLimit: defaultSitemapLimit,
+		nextID:       repo.MaxID() + 1,
+		runs:         make(map[int]*taskRun),
+		monitors:     make(map[int]*models.Monitor),
+		monitorWake:  make(chan struct{}, 1),
+
+		idemInFlight:   make(map[string]struct{}),
+		idempotencyTTL: defaultIdempotencyTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
 
 	if s.nextID <= 1 {
 		s.nextID = 1
 	}
 
+	s.loadMonitors()
+
 	for _, task := range pending {
 		resetStalledTask(task)
 		repo.Save(task)
-		s.queue <- task
+		s.queue.restore(task)
 	}
 
 	return s
 }
 
-func (s *Service) CreateTask(ctx context.Context, links []string) (int, error) {
-	if len(links) == 0 {
+func (s *Service) CreateTask(ctx context.Context, req models.LinkRequest) (int, error) {
+	return s.createTask(ctx, req, 0, nil)
+}
+
+// createTask validates and queues a new task. A non-nil key is stored
+// together with the task.
+func (s *Service) createTask(ctx context.Context, req models.LinkRequest, monitorID int, key *models.IdempotencyKey) (int, error) {
+	if len(req.Links) == 0 && req.Sitemap == "" {
 		return 0, ErrEmptyLinks
 	}
-	if s.closed.Load() {
-		return 0, errors.New("service is shutting down")
+	if err := validateOptions(req.CheckOptions); err != nil {
+		return 0, err
 	}
-
-	task := &models.Task{
-		ID:        s.nextTaskID(),
-		CreatedAt: clock.Now(),
-		Status:    models.StatusPending,
-		Results:   make([]models.LinkStatus, len(links)),
+	if _, ok := priorityLevels[req.Priority]; req.Priority != "" && !ok {
+		return 0, fmt.Errorf("%w: %q, use low, normal, high or urgent", ErrInvalidPriority, req.Priority)
+	}
+	createdAt := clock.Now()
+	budget, err := taskBudget(req, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	callback, err := s.newCallback(req)
+	if err != nil {
+		return 0, err
 	}
 
-	for i, url := range links {
-		task.Results[i] = models.LinkStatus{
-			URL:    url,
-			Status: models.StatusPending,
+	taskType := models.TaskTypeLinks
+	var crawl *models.CrawlOptions
+	if req.Crawl != nil {
+		if s.fetcher == nil {
+			return 0, fmt.Errorf("%w: crawling is not configured", ErrInvalidCrawl)
+		}
+		opts, err := normalizeCrawlOptions(*req.Crawl)
+		if err != nil {
+			return 0, err
 		}
+		taskType, crawl = models.TaskTypeCrawl, &opts
+	}
+	locations, err := normalizeLocations(req.Locations, s.agents != nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(locations) > 0 && crawl != nil {
+		return 0, fmt.Errorf("%w: crawling runs on the server only", ErrInvalidLocations)
+	}
+	if err := s.checkSecrets(req.CheckOptions, locations); err != nil {
+		return 0, err
+	}
+	// Fail fast before the sitemap is fetched; offer below decides.
+	if err := s.queue.admit(); err != nil {
+		return 0, err
 	}
 
-	s.repo.Save(task)
+	results := newResultSet(len(req.Links), req.StripTracking)
+	for _, link := range req.Links {
+		results.add(link, models.LinkStatus{Status: models.StatusPending})
+	}
+
+	if req.Sitemap != "" {
+		entries, err := s.expandSitemap(ctx, req.Sitemap, req.CheckOptions)
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			results.add(entry.Loc, models.LinkStatus{
+				Status:  models.StatusPending,
+				LastMod: entry.LastMod,
+			})
+		}
+		if crawl == nil {
+			taskType = models.TaskTypeSitemap
+		}
+	}
+	if len(results.items) == 0 {
+		return 0, ErrEmptyLinks
+	}
+	if len(locations) > 0 {
+		results.items = expandLocations(results.items, locations)
+	}
 
-	select {
-	case s.queue <- task:
-	case <-ctx.Done():
-		return 0, ctx.Err()
+	task := &models.Task{
+		Type:      taskType,
+		Priority:  req.Priority,
+		CreatedAt: createdAt,
+		Status:    models.StatusPending,
+		Options:   req.CheckOptions,
+		Crawl:     crawl,
+		Sitemap:   req.Sitemap,
+		Locations: locations,
+		Budget:    budget,
+		MonitorID: monitorID,
+		Callback:  callback,
+		Results:   results.items,
 	}
 
+	err = s.queue.offer(task, func() {
+		task.ID = s.nextTaskID()
+		if key == nil {
+			s.repo.Save(task)
+			return
+		}
+		key.TaskID, key.CreatedAt = task.ID, createdAt
+		s.repo.SaveWithKey(task, key)
+	})
+	if err != nil {
+		return 0, err
+	}
 	return task.ID, nil
 }
 
@@ -120,6 +254,12 @@ func (s *Service) GenerateReport(ctx context.Context, ids []int) ([]byte, error)
 	return data, nil
 }
 
+// QueuePosition returns the 1-based place of a task waiting in the queue,
+// or 0 when it is not queued.
+func (s *Service) QueuePosition(id int) int {
+	return s.queue.position(id)
+}
+
 func (s *Service) nextTaskID() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -129,14 +269,120 @@ func (s *Service) nextTaskID() int {
 }
 
 func (s *Service) CloseQueue() {
-	s.closeW.Do(func() {
-		s.closed.Store(true)
-		close(s.queue)
-	})
+	s.queue.close()
+}
+
+// Credentials never reach the stored task: sensitive values must be secret references.
+func validateOptions(opts models.CheckOptions) error {
+	if err := validateHeaders(opts.Headers, false); err != nil {
+		return err
+	}
+	if opts.Transaction != nil {
+		if err := validateTransaction(opts.Transaction); err != nil {
+			return err
+		}
+	}
+
+	if opts.Auth == nil {
+		return nil
+	}
+	if opts.Auth.Secret == "" {
+		return fmt.Errorf("%w: auth secret is required", ErrInvalidAuth)
+	}
+	switch opts.Auth.Type {
+	case models.AuthBasic:
+		if opts.Auth.Username == "" {
+			return fmt.Errorf("%w: basic auth username is required", ErrInvalidAuth)
+		}
+	case models.AuthBearer:
+	default:
+		return fmt.Errorf("%w: unsupported auth type %q", ErrInvalidAuth, opts.Auth.Type)
+	}
+	return nil
+}
+
+// checkSecrets makes sure every secret the options reference exists now, so
+// a typo is reported to the client and not as a failed check. Agents use
+// their own stores, so only checks run by the server are verified.
+func (s *Service) checkSecrets(opts models.CheckOptions, locations []string) error {
+	if len(locations) > 0 && !slices.Contains(locations, models.LocationLocal) {
+		return nil
+	}
+	for _, name := range secretNames(opts) {
+		if s.secrets == nil {
+			return fmt.Errorf("%w: secret %q requested but no secret store is configured", ErrInvalidAuth, name)
+		}
+		if _, ok := s.secrets.Lookup(name); !ok {
+			return fmt.Errorf("%w: secret %q not found", ErrInvalidAuth, name)
+		}
+	}
+	return nil
+}
+
+// secretNames lists the secrets referenced by headers, auth and transaction
+// steps.
+func secretNames(opts models.CheckOptions) []string {
+	var names []string
+	addHeaders := func(headers []models.Header) {
+		for _, h := range headers {
+			if h.Secret != "" {
+				names = append(names, h.Secret)
+			}
+		}
+	}
+	addHeaders(opts.Headers)
+	if opts.Auth != nil {
+		names = append(names, opts.Auth.Secret)
+	}
+	if opts.Transaction != nil {
+		for _, step := range opts.Transaction.Steps {
+			addHeaders(step.Headers)
+			for _, field := range step.Form {
+				if field.Secret != "" {
+					names = append(names, field.Secret)
+				}
+			}
+			for _, match := range bodySecretRe.FindAllStringSubmatch(step.Body, -1) {
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+// validateHeaders keeps credentials out of stored tasks. Transaction steps
+// may also fill sensitive headers from values captured at check time.
+func validateHeaders(headers []models.Header, allowCaptures bool) error {
+	for _, h := range headers {
+		if strings.TrimSpace(h.Name) == "" {
+			return fmt.Errorf("%w: header name is required", ErrInvalidAuth)
+		}
+		if (h.Value == "") == (h.Secret == "") {
+			return fmt.Errorf("%w: header %q needs either value or secret", ErrInvalidAuth, h.Name)
+		}
+		if h.Value != "" && isSensitiveHeader(h.Name) && !(allowCaptures && captureOnlyValue.MatchString(h.Value)) {
+			return fmt.Errorf("%w: header %q must reference a secret", ErrInvalidAuth, h.Name)
+		}
+	}
+	return nil
+}
+
+// sensitiveHeaderWords mark header names that usually carry credentials,
+// such as X-Api-Key, X-Auth-Token or Set-Cookie.
+var sensitiveHeaderWords = []string{"auth", "token", "key", "secret", "password", "cookie", "session"}
+
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
 }
 
 func resetStalledTask(task *models.Task) {
-	if task.Status == models.StatusDone {
+	if isFinished(task.Status) {
 		return
 	}
 
@@ -1,9 +1,21 @@
 package service
 
 import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/internal/worker"
 	"github.com/whiterage/14-11-2025/pkg/models"
 )
 
@@ -31,3 +43,212 @@ func TestResetStalledTask(t *testing.T) {
 		t.Fatalf("completed result should be kept")
 	}
 }
+
+func TestValidateOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    models.CheckOptions
+		wantErr bool
+	}{
+		{"empty", models.CheckOptions{}, false},
+		{"plain header", models.CheckOptions{Headers: []models.Header{{Name: "X-Env", Value: "staging"}}}, false},
+		{"secret header", models.CheckOptions{Headers: []models.Header{{Name: "Authorization", Secret: "token"}}}, false},
+		{"raw authorization", models.CheckOptions{Headers: []models.Header{{Name: "authorization", Value: "Bearer abc"}}}, true},
+		{"raw api key", models.CheckOptions{Headers: []models.Header{{Name: "X-Api-Key", Value: "abc"}}}, true},
+		{"raw auth token", models.CheckOptions{Headers: []models.Header{{Name: "X-Auth-Token", Value: "abc"}}}, true},
+		{"secret api key", models.CheckOptions{Headers: []models.Header{{Name: "X-Api-Key", Secret: "api_key"}}}, false},
+		{"basic auth", models.CheckOptions{Auth: &models.Auth{Type: models.AuthBasic, Username: "bot", Secret: "pwd"}}, false},
+		{"basic without user", models.CheckOptions{Auth: &models.Auth{Type: models.AuthBasic, Secret: "pwd"}}, true},
+		{"bearer without secret", models.CheckOptions{Auth: &models.Auth{Type: models.AuthBearer}}, true},
+		{"unknown auth", models.CheckOptions{Auth: &models.Auth{Type: "digest", Secret: "pwd"}}, true},
+		{"transaction", models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/login", Form: []models.FormField{{Name: "password", Secret: "pwd"}}, Capture: []models.Capture{{Name: "sid", Cookie: "session"}}},
+			{URL: "/account", Headers: []models.Header{{Name: "Cookie", Value: "session={{sid}}"}}},
+		}}}, false},
+		{"transaction raw password", models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/login", Form: []models.FormField{{Name: "password", Value: "hunter2"}}},
+		}}}, true},
+		{"transaction bad capture", models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{Capture: []models.Capture{{Name: "csrf", Regex: "("}}},
+		}}}, true},
+		{"transaction bearer capture", models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/api", Headers: []models.Header{{Name: "Authorization", Value: "Bearer {{token}}"}}},
+		}}}, false},
+		{"transaction raw token next to capture", models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/api", Headers: []models.Header{{Name: "Authorization", Value: "Bearer abc{{token}}"}}},
+		}}}, true},
+		{"transaction json body secret", models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/login", Method: "POST", Body: `{"user":"bot","auth":{"password":"{{secret:pwd}}"}}`},
+		}}}, false},
+		{"transaction raw json password", models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/login", Method: "POST", Body: `{"user":"bot","auth":{"password":"hunter2"}}`},
+		}}}, true},
+		{"transaction raw form body password", models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/login", Method: "POST", Body: "user=bot&passwd=hunter2"},
+		}}}, true},
+		{"transaction without steps", models.CheckOptions{Transaction: &models.Transaction{}}, true},
+	}
+
+	for _, tt := range tests {
+		err := validateOptions(tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+}
+
+func TestCreateTask_RequiresExistingSecrets(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 10, WithSecrets(secretMap{"pwd": "hunter2"}))
+	plain := NewService(repository.NewMemoryRepo(), fakeSite{}, 10)
+
+	tests := []struct {
+		name string
+		svc  *Service
+		opts models.CheckOptions
+		ok   bool
+	}{
+		{"known auth secret", svc, models.CheckOptions{Auth: &models.Auth{Type: models.AuthBearer, Secret: "pwd"}}, true},
+		{"unknown auth secret", svc, models.CheckOptions{Auth: &models.Auth{Type: models.AuthBearer, Secret: "pdw"}}, false},
+		{"unknown header secret", svc, models.CheckOptions{Headers: []models.Header{{Name: "X-Api-Key", Secret: "api_key"}}}, false},
+		{"unknown form secret", svc, models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/login", Form: []models.FormField{{Name: "password", Secret: "other"}}},
+		}}}, false},
+		{"unknown body secret", svc, models.CheckOptions{Transaction: &models.Transaction{Steps: []models.Step{
+			{URL: "/login", Method: "POST", Body: `{"password":"{{secret:other}}"}`},
+		}}}, false},
+		{"no secret store", plain, models.CheckOptions{Auth: &models.Auth{Type: models.AuthBearer, Secret: "pwd"}}, false},
+		{"no secrets needed", plain, models.CheckOptions{}, true},
+	}
+	for _, tt := range tests {
+		_, err := tt.svc.CreateTask(context.Background(), models.LinkRequest{Links: []string{"https://example.com"}, CheckOptions: tt.opts})
+		if tt.ok && err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidAuth) {
+			t.Fatalf("%s: expected ErrInvalidAuth, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestCreateTask_MergesDuplicates(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 1)
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+		Links: []string{
+			"Example.com/",
+			"https://example.com:443/#top",
+			"example.com",
+			"https://example.com/page?utm_source=mail",
+			"https://example.com/page",
+			"президент.рф",
+		},
+		CheckOptions: models.CheckOptions{StripTracking: true},
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	task, _ := svc.GetTask(id)
+	want := []models.LinkStatus{
+		{URL: "https://example.com", Inputs: []string{"Example.com/", "https://example.com:443/#top", "example.com"}},
+		{URL: "https://example.com/page", Inputs: []string{"https://example.com/page?utm_source=mail", "https://example.com/page"}},
+		{URL: "https://xn--d1abbgf6aiiy.xn--p1ai", Inputs: []string{"президент.рф"}},
+	}
+	if len(task.Results) != len(want) {
+		t.Fatalf("unexpected results: %+v", task.Results)
+	}
+	for i, res := range task.Results {
+		if res.URL != want[i].URL || !reflect.DeepEqual(res.Inputs, want[i].Inputs) {
+			t.Fatalf("result %d: got %s %v, want %s %v", i, res.URL, res.Inputs, want[i].URL, want[i].Inputs)
+		}
+	}
+}
+
+func TestSecretsStayOutOfStorageAndReport(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "bot" || pass != "hunter2-value" || r.Header.Get("X-Api-Key") != "key-7-value" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo, err := repository.NewPersistentRepo(path)
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	secrets := secretMap{"bot_password": "hunter2-value", "api_key": "key-7-value"}
+	checker := worker.NewHTTPChecker(2*time.Second, worker.WithSecrets(secrets))
+	svc := NewService(repo, checker, 1, WithSecrets(secrets))
+
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+		Links: []string{srv.URL},
+		CheckOptions: models.CheckOptions{
+			Headers: []models.Header{{Name: "X-Api-Key", Secret: "api_key"}},
+			Auth:    &models.Auth{Type: models.AuthBasic, Username: "bot", Secret: "bot_password"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	queued, _ := svc.queue.pop(context.Background())
+	NewWorkerPool(svc, 1).processTask(context.Background(), queued)
+
+	task, _ := svc.GetTask(id)
+	if task.Results[0].Result != models.ResultOK {
+		t.Fatalf("secrets not applied: %+v", task.Results[0])
+	}
+
+	stored, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read storage: %v", err)
+	}
+	if !bytes.Contains(stored, []byte("bot_password")) || !bytes.Contains(stored, []byte("api_key")) {
+		t.Fatalf("secret names not stored:\n%s", stored)
+	}
+	report, err := svc.GenerateReport(context.Background(), []int{id})
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	text := inflatePDF(t, report)
+	if !bytes.Contains(text, []byte("Links Status Report")) {
+		t.Fatalf("report text not readable")
+	}
+	for _, data := range [][]byte{stored, text} {
+		for _, value := range []string{"hunter2-value", "key-7-value"} {
+			if bytes.Contains(data, []byte(value)) {
+				t.Fatalf("secret value %q leaked", value)
+			}
+		}
+	}
+}
+
+// inflatePDF returns the PDF with its compressed streams expanded, so the
+// page text can be searched.
+func inflatePDF(t *testing.T, data []byte) []byte {
+	t.Helper()
+	out := bytes.Clone(data)
+	for rest := data; ; {
+		start := bytes.Index(rest, []byte("stream\n"))
+		if start < 0 {
+			return out
+		}
+		rest = rest[start+len("stream\n"):]
+		end := bytes.Index(rest, []byte("\nendstream"))
+		if end < 0 {
+			return out
+		}
+		if r, err := zlib.NewReader(bytes.NewReader(rest[:end])); err == nil {
+			text, _ := io.ReadAll(r)
+			out = append(out, text...)
+		}
+		rest = rest[end:]
+	}
+}
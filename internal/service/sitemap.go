@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+	"github.com/whiterage/14-11-2025/pkg/sitemap"
+)
+
+const (
+	defaultSitemapLimit = 10000
+	maxSitemapNesting   = 3
+)
+
+var (
+	ErrInvalidSitemap  = errors.New("invalid sitemap")
+	ErrSitemapTooLarge = errors.New("sitemap lists too many urls")
+)
+
+func WithSitemapLimit(limit int) Option {
+	return func(s *Service) {
+		if limit > 0 {
+			s.sitemapLimit = limit
+		}
+	}
+}
+
+// expandSitemap walks a sitemap (following sitemap indexes) and returns every
+// listed URL, failing as soon as the total exceeds the configured limit.
+func (s *Service) expandSitemap(ctx context.Context, rawURL string, opts models.CheckOptions) ([]sitemap.Entry, error) {
+	if s.fetcher == nil {
+		return nil, fmt.Errorf("%w: sitemap fetching is not configured", ErrInvalidSitemap)
+	}
+
+	root, err := normalizeURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSitemap, err)
+	}
+
+	type pending struct {
+		url   string
+		depth int
+	}
+
+	var entries []sitemap.Entry
+	seenURLs := make(map[string]bool)
+	seenMaps := map[string]bool{root: true}
+	queue := []pending{{url: root}}
+
+	fetch := s.fetcher.Fetch
+	if fetcher, ok := s.fetcher.(SitemapFetcher); ok {
+		fetch = fetcher.FetchSitemap
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		page, err := fetch(ctx, current.url, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: fetch %s: %v", ErrInvalidSitemap, current.url, err)
+		}
+		if page.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%w: fetch %s: status %d", ErrInvalidSitemap, current.url, page.StatusCode)
+		}
+
+		doc, err := sitemap.Parse(page.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parse %s: %v", ErrInvalidSitemap, current.url, err)
+		}
+
+		for _, entry := range doc.URLs {
+			if seenURLs[entry.Loc] {
+				continue
+			}
+			seenURLs[entry.Loc] = true
+			entries = append(entries, entry)
+			if len(entries) > s.sitemapLimit {
+				return nil, fmt.Errorf("%w: more than %d urls listed, split the sitemap or raise the limit", ErrSitemapTooLarge, s.sitemapLimit)
+			}
+		}
+
+		for _, child := range doc.Sitemaps {
+			if current.depth+1 > maxSitemapNesting {
+				return nil, fmt.Errorf("%w: sitemap indexes nested deeper than %d levels", ErrInvalidSitemap, maxSitemapNesting)
+			}
+			if !seenMaps[child] {
+				seenMaps[child] = true
+				queue = append(queue, pending{url: child, depth: current.depth + 1})
+			}
+		}
+	}
+
+	return entries, nil
+}
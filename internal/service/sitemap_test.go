@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestCreateTask_Sitemap(t *testing.T) {
+	t.Parallel()
+
+	site := fakeSite{
+		"https://example.com/sitemap.xml": `<sitemapindex>
+  <sitemap><loc>https://example.com/pages.xml</loc></sitemap>
+  <sitemap><loc>https://example.com/blog.xml</loc></sitemap>
+</sitemapindex>`,
+		"https://example.com/pages.xml": `<urlset>
+  <url><loc>https://example.com/</loc><lastmod>2025-11-01</lastmod></url>
+  <url><loc>https://example.com/about</loc></url>
+</urlset>`,
+		"https://example.com/blog.xml": `<urlset>
+  <url><loc>https://example.com/blog/1</loc></url>
+  <url><loc>https://example.com/about</loc></url>
+</urlset>`,
+	}
+
+	svc := NewService(repository.NewMemoryRepo(), site, 1, WithFetcher(site))
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{Sitemap: "example.com/sitemap.xml"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	task, _ := svc.GetTask(id)
+	if task.Type != models.TaskTypeSitemap || len(task.Results) != 3 {
+		t.Fatalf("unexpected task: %+v", task)
+	}
+	if task.Results[0].URL != "https://example.com" || task.Results[0].LastMod != "2025-11-01" {
+		t.Fatalf("lastmod not carried over: %+v", task.Results[0])
+	}
+
+	limited := NewService(repository.NewMemoryRepo(), site, 1, WithFetcher(site), WithSitemapLimit(2))
+	_, err = limited.CreateTask(context.Background(), models.LinkRequest{Sitemap: "https://example.com/sitemap.xml"})
+	if !errors.Is(err, ErrSitemapTooLarge) {
+		t.Fatalf("expected ErrSitemapTooLarge, got %v", err)
+	}
+}
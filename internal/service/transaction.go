@@ -0,0 +1,145 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+const maxTransactionSteps = 20
+
+var transactionMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+func validateTransaction(tx *models.Transaction) error {
+	if len(tx.Steps) == 0 || len(tx.Steps) > maxTransactionSteps {
+		return fmt.Errorf("%w: between 1 and %d steps are required", ErrInvalidTransaction, maxTransactionSteps)
+	}
+
+	for i, step := range tx.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+
+		if step.Method != "" && !transactionMethods[strings.ToUpper(step.Method)] {
+			return fmt.Errorf("%w: %s: unsupported method %q", ErrInvalidTransaction, name, step.Method)
+		}
+		if len(step.Form) > 0 && step.Body != "" {
+			return fmt.Errorf("%w: %s: form and body are mutually exclusive", ErrInvalidTransaction, name)
+		}
+		if err := validateHeaders(step.Headers, true); err != nil {
+			return err
+		}
+		if step.Body != "" && !bodySecretsReferenced(step.Body) {
+			return fmt.Errorf("%w: %s: password fields in the body must use {{secret:name}}", ErrInvalidAuth, name)
+		}
+
+		for _, field := range step.Form {
+			if strings.TrimSpace(field.Name) == "" {
+				return fmt.Errorf("%w: %s: form field name is required", ErrInvalidTransaction, name)
+			}
+			if field.Value != "" && field.Secret != "" {
+				return fmt.Errorf("%w: %s: form field %q has both value and secret", ErrInvalidTransaction, name, field.Name)
+			}
+			if field.Value != "" && isPasswordField(field.Name) {
+				return fmt.Errorf("%w: %s: form field %q must reference a secret", ErrInvalidAuth, name, field.Name)
+			}
+		}
+
+		for _, capture := range step.Capture {
+			sources := 0
+			for _, source := range []string{capture.Cookie, capture.Header, capture.Regex} {
+				if source != "" {
+					sources++
+				}
+			}
+			if capture.Name == "" || sources != 1 {
+				return fmt.Errorf("%w: %s: a capture needs a name and exactly one of cookie, header or regex", ErrInvalidTransaction, name)
+			}
+			if capture.Regex != "" {
+				if _, err := regexp.Compile(capture.Regex); err != nil {
+					return fmt.Errorf("%w: %s: capture %q: %v", ErrInvalidTransaction, name, capture.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isPasswordField(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "password") || strings.Contains(name, "passwd")
+}
+
+// captureOnlyValue is a header value built only from captures, with an
+// optional auth scheme or cookie name in front: "Bearer {{token}}",
+// "session={{sid}}".
+var captureOnlyValue = regexp.MustCompile(`^(?:(?i:bearer|basic|token) +|[A-Za-z0-9_-]+=)?(?:\{\{\s*[A-Za-z0-9_.-]+\s*\}\})+$`)
+
+var secretRef = regexp.MustCompile(`^\{\{\s*secret:[A-Za-z0-9_.-]+\s*\}\}$`)
+
+// bodySecretRe finds the {{secret:name}} references of a step body.
+var bodySecretRe = regexp.MustCompile(`\{\{\s*secret:([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// bodySecretsReferenced reports whether every password-like field of a JSON
+// or form-encoded body is a {{secret:name}} reference.
+func bodySecretsReferenced(body string) bool {
+	var doc any
+	if err := json.Unmarshal([]byte(body), &doc); err == nil {
+		return jsonSecretsReferenced(doc)
+	}
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return true
+	}
+	for name, list := range values {
+		if !isPasswordField(name) {
+			continue
+		}
+		for _, value := range list {
+			if !secretRef.MatchString(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func jsonSecretsReferenced(doc any) bool {
+	switch v := doc.(type) {
+	case map[string]any:
+		for name, value := range v {
+			if isPasswordField(name) {
+				if text, ok := value.(string); !ok || !secretRef.MatchString(text) {
+					return false
+				}
+				continue
+			}
+			if !jsonSecretsReferenced(value) {
+				return false
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if !jsonSecretsReferenced(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
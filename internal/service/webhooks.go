@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var ErrInvalidCallback = errors.New("invalid callback")
+
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookMaxAttempts = 8
+	webhookBaseDelay   = 10 * time.Second
+	webhookMaxDelay    = time.Hour
+	// webhookPoll bounds the outbox sleep, so tasks finished by paths that
+	// do not wake it are still delivered.
+	webhookPoll = 5 * time.Second
+	// webhookConcurrency bounds parallel deliveries, so one slow receiver
+	// holds up a pass for at most webhookTimeout.
+	webhookConcurrency = 8
+)
+
+type SecretLookup interface {
+	Lookup(name string) (string, bool)
+}
+
+func WithWebhooks(outbox *WebhookOutbox) Option {
+	return func(s *Service) {
+		s.webhooks = outbox
+		outbox.repo = s.repo
+	}
+}
+
+// WebhookOutbox posts finished tasks to their callback URL. Delivery state
+// lives on the stored task, so pending deliveries survive restarts. Failed
+// attempts are retried with exponential backoff.
+type WebhookOutbox struct {
+	repo      *repository.MemoryRepo
+	secrets   SecretLookup
+	client    *http.Client
+	baseDelay time.Duration
+	wake      chan struct{}
+}
+
+func NewWebhookOutbox(secrets SecretLookup) *WebhookOutbox {
+	return &WebhookOutbox{
+		secrets: secrets,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			// A redirect would turn the POST into a GET; report it instead.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		baseDelay: webhookBaseDelay,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+func (o *WebhookOutbox) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(o.deliverDue(ctx))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-o.wake:
+			timer.Stop()
+		}
+	}
+}
+
+func (o *WebhookOutbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDue sends every due delivery and returns how long to sleep.
+func (o *WebhookOutbox) deliverDue(ctx context.Context) time.Duration {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, webhookConcurrency)
+	)
+	wait := webhookPoll
+	for _, task := range o.repo.PendingCallbacks() {
+		if ctx.Err() != nil {
+			break
+		}
+		if !isFinished(task.Status) {
+			continue
+		}
+		if next := task.Callback.NextAttempt; next != nil {
+			if until := time.Until(*next); until > 0 {
+				wait = min(wait, until)
+				continue
+			}
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(task *models.Task) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			o.deliver(ctx, task)
+		}(task)
+	}
+	wg.Wait()
+	return wait
+}
+
+func (o *WebhookOutbox) deliver(ctx context.Context, task *models.Task) {
+	cb := task.Callback
+	cb.Attempts++
+	code, err := o.post(ctx, task)
+	cb.LastStatusCode = code
+	cb.LastError = ""
+	now := clock.Now()
+
+	switch {
+	case err == nil:
+		cb.Status = models.CallbackDelivered
+		cb.DeliveredAt = &now
+		cb.NextAttempt = nil
+	case cb.Attempts >= webhookMaxAttempts:
+		cb.Status = models.CallbackFailed
+		cb.LastError = err.Error()
+		cb.NextAttempt = nil
+	default:
+		cb.LastError = err.Error()
+		next := now.Add(o.backoff(cb.Attempts))
+		cb.NextAttempt = &next
+	}
+	// A task deleted while its callback was being sent stays deleted.
+	o.repo.Replace(task)
+}
+
+func (o *WebhookOutbox) post(ctx context.Context, task *models.Task) (int, error) {
+	secret, ok := o.secrets.Lookup(task.Callback.Secret)
+	if !ok {
+		return 0, fmt.Errorf("secret %q not found", task.Callback.Secret)
+	}
+
+	payload := *task
+	payload.Callback = nil
+	body, err := json.Marshal(&payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.Callback.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature-256", Sign(secret, body))
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(task.Callback.Attempts))
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (o *WebhookOutbox) backoff(attempts int) time.Duration {
+	delay := o.baseDelay << (attempts - 1)
+	if delay <= 0 || delay > webhookMaxDelay {
+		return webhookMaxDelay
+	}
+	return delay
+}
+
+// Sign returns the X-Signature-256 value for a webhook body: the hex
+// HMAC-SHA256 of the body, prefixed with "sha256=".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newCallback validates the callback of a new task. The secret must exist
+// now, so a typo is reported to the client and not at delivery time.
+func (s *Service) newCallback(req models.LinkRequest) (*models.Callback, error) {
+	if req.CallbackURL == "" {
+		if req.CallbackSecret != "" {
+			return nil, fmt.Errorf("%w: callback_secret needs callback_url", ErrInvalidCallback)
+		}
+		return nil, nil
+	}
+	if s.webhooks == nil {
+		return nil, fmt.Errorf("%w: callbacks are not configured", ErrInvalidCallback)
+	}
+	target, err := url.Parse(req.CallbackURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: callback_url must be an absolute http(s) URL", ErrInvalidCallback)
+	}
+	if req.CallbackSecret == "" {
+		return nil, fmt.Errorf("%w: callback_secret is required", ErrInvalidCallback)
+	}
+	if _, ok := s.webhooks.secrets.Lookup(req.CallbackSecret); !ok {
+		return nil, fmt.Errorf("%w: secret %q not found", ErrInvalidCallback, req.CallbackSecret)
+	}
+	return &models.Callback{URL: req.CallbackURL, Secret: req.CallbackSecret, Status: models.CallbackPending}, nil
+}
+
+// taskFinished wakes the outbox for tasks with a callback.
+func (s *Service) taskFinished(task *models.Task) {
+	if s.webhooks != nil && task.Callback != nil {
+		s.webhooks.notify()
+	}
+}
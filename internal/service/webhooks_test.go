@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/internal/repository"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+type secretMap map[string]string
+
+func (m secretMap) Lookup(name string) (string, bool) {
+	value, ok := m[name]
+	return value, ok
+}
+
+func TestWebhookOutbox_SignsAndRetries(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	var payload models.Task
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get("X-Signature-256"); got != Sign("s3cret", body) {
+			t.Errorf("bad signature %q", got)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.Unmarshal(body, &payload)
+	}))
+	defer server.Close()
+
+	outbox := NewWebhookOutbox(secretMap{"client_a": "s3cret"})
+	outbox.baseDelay = 20 * time.Millisecond
+	svc := NewService(repository.NewMemoryRepo(), &recordingChecker{}, 5, WithWebhooks(outbox))
+	id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+		Links:          []string{"https://example.com"},
+		CallbackURL:    server.URL,
+		CallbackSecret: "client_a",
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	queued, _ := svc.queue.pop(context.Background())
+	NewWorkerPool(svc, 1).processTask(context.Background(), queued)
+
+	ctx := context.Background()
+	outbox.deliverDue(ctx)
+	task, _ := svc.GetTask(id)
+	if task.Callback.Status != models.CallbackPending || task.Callback.LastStatusCode != http.StatusBadGateway || task.Callback.NextAttempt == nil {
+		t.Fatalf("failed attempt not scheduled for retry: %+v", task.Callback)
+	}
+
+	outbox.deliverDue(ctx)
+	task, _ = svc.GetTask(id)
+	if calls.Load() != 1 {
+		t.Fatalf("retried before backoff elapsed")
+	}
+
+	time.Sleep(time.Until(*task.Callback.NextAttempt))
+	outbox.deliverDue(ctx)
+	task, _ = svc.GetTask(id)
+	if task.Callback.Status != models.CallbackDelivered || task.Callback.Attempts != 2 {
+		t.Fatalf("unexpected delivery state: %+v", task.Callback)
+	}
+	if payload.ID != id || payload.Status != models.StatusDone || payload.Callback != nil {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestWebhookOutbox_DeliversConcurrentlyAndRespectsDeletes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		svc     *Service
+		arrived = make(chan struct{}, 2)
+		release = make(chan struct{})
+		deleted atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		if r.URL.Path == "/delete" {
+			var payload models.Task
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			_ = svc.DeleteTask(context.Background(), payload.ID)
+			deleted.Store(int32(payload.ID))
+		}
+	}))
+	defer server.Close()
+
+	outbox := NewWebhookOutbox(secretMap{"client_a": "s3cret"})
+	svc = NewService(repository.NewMemoryRepo(), &recordingChecker{}, 5, WithWebhooks(outbox))
+	ids := make([]int, 0, 2)
+	for _, path := range []string{"/keep", "/delete"} {
+		id, err := svc.CreateTask(context.Background(), models.LinkRequest{
+			Links:          []string{"https://example.com" + path},
+			CallbackURL:    server.URL + path,
+			CallbackSecret: "client_a",
+		})
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		ids = append(ids, id)
+		queued, _ := svc.queue.pop(context.Background())
+		NewWorkerPool(svc, 1).processTask(context.Background(), queued)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		outbox.deliverDue(context.Background())
+		close(done)
+	}()
+	for range 2 {
+		select {
+		case <-arrived:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("deliveries are sent one at a time")
+		}
+	}
+	close(release)
+	<-done
+
+	if int(deleted.Load()) != ids[1] {
+		t.Fatalf("receiver did not delete task %d", ids[1])
+	}
+	if _, err := svc.GetTask(ids[1]); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("deleted task brought back by its delivery: %v", err)
+	}
+	if task, _ := svc.GetTask(ids[0]); task.Callback.Status != models.CallbackDelivered {
+		t.Fatalf("unexpected delivery state: %+v", task.Callback)
+	}
+}
+
+func TestNewCallback(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(repository.NewMemoryRepo(), fakeSite{}, 5, WithWebhooks(NewWebhookOutbox(secretMap{"client_a": "s3cret"})))
+	plain := NewService(repository.NewMemoryRepo(), fakeSite{}, 5)
+
+	tests := []struct {
+		name string
+		svc  *Service
+		req  models.LinkRequest
+		ok   bool
+	}{
+		{name: "valid", svc: svc, req: models.LinkRequest{CallbackURL: "https://hooks.example.com/done", CallbackSecret: "client_a"}, ok: true},
+		{name: "no callback", svc: svc, req: models.LinkRequest{}, ok: true},
+		{name: "missing secret", svc: svc, req: models.LinkRequest{CallbackURL: "https://hooks.example.com/done"}},
+		{name: "unknown secret", svc: svc, req: models.LinkRequest{CallbackURL: "https://hooks.example.com/done", CallbackSecret: "client_b"}},
+		{name: "relative url", svc: svc, req: models.LinkRequest{CallbackURL: "/done", CallbackSecret: "client_a"}},
+		{name: "not configured", svc: plain, req: models.LinkRequest{CallbackURL: "https://hooks.example.com/done", CallbackSecret: "client_a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tt.svc.newCallback(tt.req)
+			if tt.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidCallback) {
+				t.Fatalf("expected ErrInvalidCallback, got %v", err)
+			}
+		})
+	}
+}
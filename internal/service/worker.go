@@ -3,37 +3,132 @@ package service
 import (
 	"context"
 	"errors"
-	"net/url"
-	"strings"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/whiterage/14-11-2025/pkg/clock"
 	"github.com/whiterage/14-11-2025/pkg/models"
+	"github.com/whiterage/14-11-2025/pkg/urlnorm"
 )
 
+var ErrInvalidPoolSize = errors.New("invalid pool size")
+
+// WorkerPool checks links with a number of workers that can be changed at
+// runtime with Resize.
 type WorkerPool struct {
 	service *Service
-	workers int
+	sched   *scheduler
 	wg      sync.WaitGroup
 	cancel  context.CancelFunc
+	latency latencyStats
+
+	mu      sync.Mutex
+	ctx     context.Context
+	size    int
+	stops   []context.CancelFunc
+	stopped bool
 }
 
+const minActiveTasks = 16
+
 func NewWorkerPool(service *Service, workers int) *WorkerPool {
 	if workers <= 0 {
 		workers = 1
 	}
 	return &WorkerPool{
 		service: service,
-		workers: workers,
+		size:    workers,
+		sched:   newScheduler(activeTaskLimit(workers)),
 	}
 }
 
 func (wp *WorkerPool) Start(ctx context.Context) {
 	workerCtx, cancel := context.WithCancel(ctx)
 	wp.cancel = cancel
-	for i := 0; i < wp.workers; i++ {
-		wp.wg.Add(1)
-		go wp.workerLoop(workerCtx)
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	wp.ctx = workerCtx
+
+	wp.wg.Add(1)
+	go wp.feed(workerCtx)
+	for len(wp.stops) < wp.size {
+		wp.spawnLocked()
+	}
+}
+
+// Resize changes the number of workers. Removed workers finish the check
+// they are running before they exit. A stopped pool cannot be resized.
+func (wp *WorkerPool) Resize(workers int) error {
+	if workers <= 0 {
+		return fmt.Errorf("%w: need at least one worker", ErrInvalidPoolSize)
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.stopped {
+		return fmt.Errorf("%w: worker pool is stopped", ErrShuttingDown)
+	}
+
+	wp.size = workers
+	wp.sched.setLimit(activeTaskLimit(workers))
+	if wp.ctx == nil {
+		return nil
+	}
+	for len(wp.stops) < workers {
+		wp.spawnLocked()
+	}
+	for len(wp.stops) > workers {
+		last := len(wp.stops) - 1
+		wp.stops[last]()
+		wp.stops = wp.stops[:last]
+	}
+	return nil
+}
+
+func (wp *WorkerPool) Size() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.size
+}
+
+// Backlog returns the number of links waiting for a worker, both in active
+// tasks and in queued ones.
+func (wp *WorkerPool) Backlog() int {
+	return wp.sched.backlog() + wp.service.queue.links()
+}
+
+// Latency returns the moving average duration of a single check.
+func (wp *WorkerPool) Latency() time.Duration {
+	return wp.latency.average()
+}
+
+func (wp *WorkerPool) spawnLocked() {
+	ctx, stop := context.WithCancel(wp.ctx)
+	wp.stops = append(wp.stops, stop)
+	wp.wg.Add(1)
+	go wp.workerLoop(ctx)
+}
+
+func activeTaskLimit(workers int) int {
+	return max(minActiveTasks, workers*4)
+}
+
+// feed moves queued tasks into the scheduler. Once the queue is closed the
+// workers finish the active tasks and exit.
+func (wp *WorkerPool) feed(ctx context.Context) {
+	defer wp.wg.Done()
+	defer wp.sched.close()
+
+	for {
+		task, ok := wp.service.queue.pop(ctx)
+		if !ok {
+			return
+		}
+		if run := wp.startRun(ctx, task); run != nil && !wp.sched.add(ctx, run) {
+			return
+		}
 	}
 }
 
@@ -41,46 +136,248 @@ func (wp *WorkerPool) workerLoop(ctx context.Context) {
 	defer wp.wg.Done()
 
 	for {
-		select {
-		case <-ctx.Done():
+		job, ok := wp.sched.next(ctx)
+		if !ok {
 			return
-		case task, ok := <-wp.service.queue:
-			if !ok {
-				return
-			}
-			wp.processTask(ctx, task)
 		}
+		wp.runJob(job)
 	}
 }
 
+// processTask checks a single task to completion on the calling goroutine.
 func (wp *WorkerPool) processTask(ctx context.Context, task *models.Task) {
-	task.Status = models.StatusProcessing
-	wp.service.repo.Save(task)
-
-	for i := range task.Results {
-		select {
-		case <-ctx.Done():
+	run := wp.startRun(ctx, task)
+	if run == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		job, ok := run.take()
+		if !ok {
 			return
-		default:
 		}
+		wp.runJob(job)
+	}
+}
+
+// startRun marks the task as processing and hands its remote links to the
+// agents. It returns nil when nothing is left for the local pool or the task
+// was cancelled before it started.
+func (wp *WorkerPool) startRun(ctx context.Context, task *models.Task) *taskRun {
+	run := &taskRun{task: task, pool: wp, finished: make(chan struct{})}
+	if task.Budget != nil {
+		run.ctx, run.cancel = context.WithDeadline(ctx, task.Budget.Deadline)
+	} else {
+		run.ctx, run.cancel = context.WithCancel(ctx)
+	}
 
-		resolvedURL, err := normalizeURL(task.Results[i].URL)
-		if err != nil {
-			task.Results[i].Status = models.StatusNotAvailable
-			task.Results[i].CheckTime = clock.Now()
+	// The task is not shared until register makes the run cancellable.
+	if task.Type == models.TaskTypeCrawl && task.Crawl != nil && wp.service.fetcher != nil {
+		run.crawl = newCrawlState(task)
+	}
+	var remote []int
+	for i, res := range task.Results {
+		if !isRemote(res) {
 			continue
 		}
+		if wp.service.agents == nil {
+			markNoAgents(&task.Results[i])
+			continue
+		}
+		remote = append(remote, i)
+	}
+	run.remote = len(remote)
+
+	if !wp.service.register(run) {
+		run.cancel()
+		return nil
+	}
+	// A cancel may already be completing the run.
+	run.mu.Lock()
+	if !run.done {
+		wp.service.repo.Save(task)
+	}
+	run.mu.Unlock()
+
+	if len(remote) > 0 {
+		wp.service.agents.dispatch(run, remote)
+	}
+
+	run.mu.Lock()
+	done := run.finishLocked()
+	run.mu.Unlock()
+	if done {
+		wp.completeRun(run)
+		return nil
+	}
+	if task.Budget != nil {
+		context.AfterFunc(run.ctx, func() {
+			if run.expired() {
+				wp.settle(run)
+			}
+		})
+	}
+	return run
+}
+
+// runJob checks one link. Results of checks aborted by a cancellation or the
+// task deadline are dropped; the link is reported as cancelled or
+// deadline_exceeded instead.
+func (wp *WorkerPool) runJob(job linkJob) {
+	run, task := job.run, job.run.task
+	ctx := run.ctx
+
+	resolvedURL, err := normalizeURL(job.url)
+	var result models.LinkStatus
+	if err != nil {
+		result = models.LinkStatus{
+			Status:    models.StatusNotAvailable,
+			Result:    models.ResultInvalidURL,
+			Error:     err.Error(),
+			CheckTime: clock.Now(),
+		}
+	} else {
+		opts := task.Options
+		if run.crawl != nil {
+			run.mu.Lock()
+			opts.KeepPage = run.crawl.mayExpand(task.Results[job.index], resolvedURL)
+			run.mu.Unlock()
+		}
+		start := time.Now()
+		result = wp.service.checker.Check(ctx, resolvedURL, opts)
+		wp.latency.observe(time.Since(start))
+	}
+	page := result.Page
+	result.Page = nil
+
+	run.mu.Lock()
+	if run.cancelled.Load() || run.expired() {
+		run.mu.Unlock()
+		wp.finishJob(run)
+		return
+	}
+	res := &task.Results[job.index]
+	wp.service.recordResult(task, res, resolvedURL, result)
+	expand := err == nil && run.crawl != nil && run.crawl.reserve(*res, resolvedURL)
+	run.mu.Unlock()
+
+	if expand {
+		links, finalURL, parsed := run.crawl.fetchLinks(ctx, wp.service.fetcher, task.Options, resolvedURL, page)
+		run.mu.Lock()
+		added := 0
+		if run.crawl.finishPage(task, job.index, finalURL, parsed) {
+			added = run.crawl.add(task, links, job.index, resolvedURL)
+		}
+		run.mu.Unlock()
+		if added > 0 {
+			wp.sched.signal()
+		}
+	}
+
+	wp.finishJob(run)
+}
+
+// recordRemote stores a result checked by an agent, or given up on by the
+// hub, with the same post-processing as a local check.
+func (wp *WorkerPool) recordRemote(run *taskRun, index int, result models.LinkStatus) {
+	run.mu.Lock()
+	if run.done || run.cancelled.Load() || run.expired() {
+		run.mu.Unlock()
+		return
+	}
+	res := &run.task.Results[index]
+	resolvedURL, err := normalizeURL(res.URL)
+	if err != nil {
+		resolvedURL = res.URL
+	}
+	wp.service.recordResult(run.task, res, resolvedURL, result)
+	run.remote--
+	done := run.finishLocked()
+	run.mu.Unlock()
+
+	if done {
+		wp.completeRun(run)
+	}
+}
+
+func (wp *WorkerPool) finishJob(run *taskRun) {
+	run.mu.Lock()
+	run.pending--
+	done := run.finishLocked()
+	run.mu.Unlock()
+
+	if done {
+		wp.completeRun(run)
+	}
+}
+
+// settle completes a cancelled or expired run once no checks are in flight;
+// otherwise the last of them does.
+func (wp *WorkerPool) settle(run *taskRun) {
+	run.mu.Lock()
+	done := run.finishLocked()
+	run.mu.Unlock()
+	if done {
+		wp.completeRun(run)
+	}
+}
+
+// completeRun stores the final status before the run is unregistered, so a
+// cancel either finds the finished run or the final status. Remote results
+// still out when the run is cancelled or expires are marked like local ones.
+func (wp *WorkerPool) completeRun(run *taskRun) {
+	defer close(run.finished)
+	s, task := wp.service, run.task
+	wp.sched.remove(run)
+	aborted := run.cancelled.Load() || run.expired()
+	run.cancel()
+	if aborted && s.agents != nil {
+		s.agents.cancel(task.ID)
+	}
+
+	run.mu.Lock()
+	if run.cancelled.Load() {
+		markCancelled(task)
+	} else {
+		if aborted {
+			markExpired(task)
+		}
+		finishTask(task)
+	}
+	s.repo.Save(task)
+	run.mu.Unlock()
+
+	s.unregister(run)
+	s.taskFinished(task)
+}
 
-		result := wp.service.checker.Check(ctx, resolvedURL)
-		task.Results[i].Status = result.Status
-		task.Results[i].CheckTime = result.CheckTime
+// latencyStats keeps an exponentially weighted moving average of check
+// durations.
+type latencyStats struct {
+	mu  sync.Mutex
+	avg time.Duration
+}
+
+func (l *latencyStats) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.avg == 0 {
+		l.avg = d
+		return
 	}
+	l.avg += (d - l.avg) / 5
+}
 
-	task.Status = models.StatusDone
-	wp.service.repo.Save(task)
+func (l *latencyStats) average() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.avg
 }
 
 func (wp *WorkerPool) Stop() {
+	wp.mu.Lock()
+	wp.stopped = true
+	wp.mu.Unlock()
+
 	if wp.cancel != nil {
 		wp.cancel()
 	}
@@ -91,21 +388,32 @@ func (wp *WorkerPool) Wait() {
 	wp.wg.Wait()
 }
 
-func normalizeURL(raw string) (string, error) {
-	value := strings.TrimSpace(raw)
-	if value == "" {
-		return "", errors.New("empty url")
-	}
-	if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
-		value = "https://" + value
+// recordResult stores the outcome of a check and tracks content changes.
+// Callers hold the run's lock.
+func (s *Service) recordResult(task *models.Task, res *models.LinkStatus, resolvedURL string, result models.LinkStatus) {
+	applyResult(res, result)
+	if task.Options.TrackChanges && result.ContentHash != "" {
+		s.trackChanges(task, res, resolvedURL, result)
 	}
+}
 
-	parsed, err := url.Parse(value)
-	if err != nil {
-		return "", err
-	}
-	if parsed.Host == "" {
-		return "", errors.New("invalid url")
-	}
-	return parsed.String(), nil
+// applyResult copies the outcome of a check onto a stored result, keeping
+// the fields that describe where the link came from.
+func applyResult(dst *models.LinkStatus, result models.LinkStatus) {
+	dst.Status = result.Status
+	dst.Availability = models.AvailabilityOf(result.Status)
+	dst.Result = result.Result
+	dst.HTTPStatus = result.HTTPStatus
+	dst.CheckTime = result.CheckTime
+	dst.Error = result.Error
+	dst.Cached = result.Cached
+	dst.Timing = result.Timing
+	dst.Details = result.Details
+	dst.Security = result.Security
+	dst.Resources = result.Resources
+	dst.Steps = result.Steps
+}
+
+func normalizeURL(raw string) (string, error) {
+	return urlnorm.Canonical(raw, urlnorm.Options{})
 }
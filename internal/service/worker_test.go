@@ -15,6 +15,8 @@ func TestNormalizeURL(t *testing.T) {
 		{"http input", "http://example.com/page", "http://example.com/page", false},
 		{"without scheme", "example.com", "https://example.com", false},
 		{"with spaces", "   yandex.ru  ", "https://yandex.ru", false},
+		{"websocket", "wss://example.com/socket", "wss://example.com/socket", false},
+		{"unknown scheme kept", "gopher://example.com", "gopher://example.com", false},
 		{"empty", "", "", true},
 	}
 
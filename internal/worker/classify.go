@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func classifyError(err error) models.ResultClass {
+	var (
+		dnsErr     *net.DNSError
+		netErr     net.Error
+		verifyErr  *tls.CertificateVerificationError
+		recordErr  tls.RecordHeaderError
+		alertErr   tls.AlertError
+		hostErr    x509.HostnameError
+		authErr    x509.UnknownAuthorityError
+		invalidErr x509.CertificateInvalidError
+		protoErr   *textproto.Error
+	)
+
+	switch {
+	case err == nil:
+		return models.ResultOK
+	case errors.As(err, &dnsErr):
+		return models.ResultDNSError
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.ResultTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return models.ResultConnectionRefused
+	case errors.As(err, &verifyErr), errors.As(err, &recordErr), errors.As(err, &alertErr),
+		errors.As(err, &hostErr), errors.As(err, &authErr), errors.As(err, &invalidErr):
+		return models.ResultTLSError
+	case errors.As(err, &protoErr):
+		return models.ResultServerError
+	default:
+		return models.ResultNetworkError
+	}
+}
+
+func classifyResponse(code int, redirected bool) models.ResultClass {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusProxyAuthRequired:
+		return models.ResultAuthRequired
+	case code >= http.StatusInternalServerError:
+		return models.ResultServerError
+	case code >= http.StatusBadRequest:
+		return models.ResultClientError
+	case code >= http.StatusMultipleChoices || redirected:
+		return models.ResultRedirect
+	default:
+		return models.ResultOK
+	}
+}
+
+// classifyGRPC maps gRPC status codes; transport failures only survive as
+// text inside codes.Unavailable.
+func classifyGRPC(err error) models.ResultClass {
+	st, ok := status.FromError(err)
+	if !ok {
+		return classifyError(err)
+	}
+
+	switch st.Code() {
+	case codes.DeadlineExceeded:
+		return models.ResultTimeout
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return models.ResultAuthRequired
+	case codes.NotFound, codes.Unimplemented, codes.InvalidArgument:
+		return models.ResultClientError
+	case codes.Unavailable:
+		msg := st.Message()
+		switch {
+		case strings.Contains(msg, "no such host"):
+			return models.ResultDNSError
+		case strings.Contains(msg, "connection refused"):
+			return models.ResultConnectionRefused
+		case strings.Contains(msg, "tls:"), strings.Contains(msg, "x509:"):
+			return models.ResultTLSError
+		}
+		return models.ResultNetworkError
+	default:
+		return models.ResultServerError
+	}
+}
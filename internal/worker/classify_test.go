@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestHTTPChecker_ResultClasses(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/private":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/broken":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/slow":
+			time.Sleep(300 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer tlsSrv.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	// Only /slow runs into the short timeout; the rest get enough time to
+	// finish a TLS handshake under the race detector.
+	checker := NewHTTPChecker(5 * time.Second)
+	impatient := NewHTTPChecker(100 * time.Millisecond)
+
+	tests := []struct {
+		url    string
+		status string
+		result models.ResultClass
+	}{
+		{srv.URL + "/ok", models.StatusAvailable, models.ResultOK},
+		{srv.URL + "/moved", models.StatusAvailable, models.ResultRedirect},
+		{srv.URL + "/private", models.StatusNotAvailable, models.ResultAuthRequired},
+		{srv.URL + "/missing", models.StatusNotAvailable, models.ResultClientError},
+		{srv.URL + "/broken", models.StatusNotAvailable, models.ResultServerError},
+		{tlsSrv.URL, models.StatusNotAvailable, models.ResultTLSError},
+		{"http://" + closedAddr, models.StatusNotAvailable, models.ResultConnectionRefused},
+		{"http://host.invalid", models.StatusNotAvailable, models.ResultDNSError},
+		{"http://bad host", models.StatusNotAvailable, models.ResultInvalidURL},
+	}
+
+	for _, tt := range tests {
+		got := checker.Check(context.Background(), tt.url, models.CheckOptions{})
+		if got.Status != tt.status || got.Result != tt.result {
+			t.Fatalf("%s: got %s/%s (%s), want %s/%s", tt.url, got.Status, got.Result, got.Error, tt.status, tt.result)
+		}
+	}
+
+	if got := impatient.Check(context.Background(), srv.URL+"/slow", models.CheckOptions{}); got.Result != models.ResultTimeout {
+		t.Fatalf("slow page: got %s/%s (%s), want timeout", got.Status, got.Result, got.Error)
+	}
+}
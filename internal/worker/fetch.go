@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+const (
+	maxPageSize = 5 << 20
+	// maxSitemapSize is the size limit of one sitemap file in the protocol.
+	maxSitemapSize = 50 << 20
+)
+
+func (c *HTTPChecker) Fetch(ctx context.Context, url string, opts models.CheckOptions) (*models.Page, error) {
+	return c.fetch(ctx, url, opts, maxPageSize)
+}
+
+// FetchSitemap downloads a sitemap, failing instead of truncating one over
+// the protocol's size limit.
+func (c *HTTPChecker) FetchSitemap(ctx context.Context, url string, opts models.CheckOptions) (*models.Page, error) {
+	page, err := c.fetch(ctx, url, opts, maxSitemapSize+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Body) > maxSitemapSize {
+		return nil, fmt.Errorf("sitemap is larger than %d MB", maxSitemapSize>>20)
+	}
+	return page, nil
+}
+
+func (c *HTTPChecker) fetch(ctx context.Context, url string, opts models.CheckOptions, limit int64) (*models.Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.admit(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := c.prepareRequest(req, opts); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Page{
+		URL:         resp.Request.URL.String(),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}, nil
+}
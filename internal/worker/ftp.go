@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"net/textproto"
+	"net/url"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// FTPChecker connects to the control port and expects a 220 greeting.
+type FTPChecker struct {
+	timeout time.Duration
+}
+
+func NewFTPChecker(timeout time.Duration) *FTPChecker {
+	return &FTPChecker{timeout: timeout}
+}
+
+func (c *FTPChecker) Check(ctx context.Context, rawURL string, opts models.CheckOptions) models.LinkStatus {
+	fail := func(err error) models.LinkStatus {
+		return models.LinkStatus{URL: rawURL, Status: models.StatusNotAvailable, Result: classifyError(err), CheckTime: clock.Now(), Error: err.Error()}
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	start := time.Now()
+	conn, err := dialTimeout(ctx, hostPort(parsed, "21"), c.timeout)
+	if err != nil {
+		return fail(err)
+	}
+	text := textproto.NewConn(conn)
+	defer text.Close()
+
+	_, banner, err := text.ReadResponse(220)
+	if err != nil {
+		return fail(err)
+	}
+	latency := millis(start, time.Now())
+
+	if id, err := text.Cmd("QUIT"); err == nil {
+		text.StartResponse(id)
+		_, _, _ = text.ReadResponse(221)
+		text.EndResponse(id)
+	}
+
+	return models.LinkStatus{
+		URL:       rawURL,
+		Status:    models.StatusAvailable,
+		Result:    models.ResultOK,
+		CheckTime: clock.Now(),
+		Timing:    &models.Timing{Total: latency},
+		Details:   map[string]string{"banner": banner},
+	}
+}
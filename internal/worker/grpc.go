@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// GRPCChecker calls grpc.health.v1.Health/Check. grpc://host:port/service
+// uses plaintext, grpcs:// uses TLS; an empty service asks about the server
+// as a whole.
+type GRPCChecker struct {
+	timeout   time.Duration
+	userAgent string
+}
+
+func NewGRPCChecker(timeout time.Duration, userAgent string) *GRPCChecker {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &GRPCChecker{timeout: timeout, userAgent: userAgent}
+}
+
+func (c *GRPCChecker) Check(ctx context.Context, rawURL string, opts models.CheckOptions) models.LinkStatus {
+	fail := func(err error) models.LinkStatus {
+		return models.LinkStatus{URL: rawURL, Status: models.StatusNotAvailable, Result: classifyGRPC(err), CheckTime: clock.Now(), Error: err.Error()}
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	creds := insecure.NewCredentials()
+	port := "80"
+	if parsed.Scheme == "grpcs" {
+		creds = credentials.NewTLS(&tls.Config{ServerName: parsed.Hostname()})
+		port = "443"
+	}
+
+	conn, err := grpc.NewClient(hostPort(parsed, port),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent(c.userAgent),
+	)
+	if err != nil {
+		return fail(err)
+	}
+	defer conn.Close()
+
+	checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	service := strings.Trim(parsed.Path, "/")
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(checkCtx, &healthpb.HealthCheckRequest{Service: service})
+	timing := &models.Timing{Total: millis(start, time.Now())}
+
+	if err != nil {
+		result := fail(err)
+		result.Timing = timing
+		switch status.Code(err) {
+		case codes.NotFound:
+			result.Details = map[string]string{"health": healthpb.HealthCheckResponse_SERVICE_UNKNOWN.String()}
+			result.Error = fmt.Sprintf("service %q is unknown to the health server", service)
+		case codes.Unimplemented:
+			result.Error = "server does not implement grpc.health.v1.Health"
+		}
+		return result
+	}
+
+	result := models.LinkStatus{
+		URL:       rawURL,
+		Status:    models.StatusNotAvailable,
+		CheckTime: clock.Now(),
+		Timing:    timing,
+		Result:    models.ResultServerError,
+		Details:   map[string]string{"health": resp.GetStatus().String()},
+	}
+	if resp.GetStatus() == healthpb.HealthCheckResponse_SERVING {
+		result.Status = models.StatusAvailable
+		result.Result = models.ResultOK
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestGRPCChecker(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("billing", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("search", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(ln)
+	defer server.Stop()
+
+	checker := NewGRPCChecker(2*time.Second, "")
+	base := "grpc://" + ln.Addr().String()
+
+	tests := []struct {
+		service string
+		status  string
+		health  string
+	}{
+		{"/billing", models.StatusAvailable, "SERVING"},
+		{"/search", models.StatusNotAvailable, "NOT_SERVING"},
+		{"/missing", models.StatusNotAvailable, "SERVICE_UNKNOWN"},
+		{"", models.StatusAvailable, "SERVING"},
+	}
+
+	for _, tt := range tests {
+		got := checker.Check(context.Background(), base+tt.service, models.CheckOptions{})
+		if got.Status != tt.status || got.Details["health"] != tt.health {
+			t.Fatalf("%q: got %s/%v (%s), want %s/%s", tt.service, got.Status, got.Details, got.Error, tt.status, tt.health)
+		}
+		if got.Timing == nil {
+			t.Fatalf("%q: latency not recorded", tt.service)
+		}
+	}
+}
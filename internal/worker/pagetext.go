@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// pageText returns the document title and its visible text with whitespace
+// collapsed; scripts, styles and templates are skipped.
+func pageText(body []byte) (string, string) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", strings.Join(strings.Fields(string(body)), " ")
+	}
+
+	var title string
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			case "title":
+				if n.FirstChild != nil && title == "" {
+					title = strings.Join(strings.Fields(n.FirstChild.Data), " ")
+				}
+				return
+			}
+		}
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteByte(' ')
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	return title, strings.Join(strings.Fields(sb.String()), " ")
+}
+
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"td": true, "th": true, "title": true, "tr": true, "ul": true,
+}
+
+// textExtract normalises a response body into stable text lines: HTML is
+// reduced to visible text with one line per block element, other content
+// types only get their whitespace normalised.
+func textExtract(body []byte, contentType string) string {
+	var raw string
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		raw = htmlLines(body)
+	} else {
+		raw = string(body)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func htmlLines(body []byte) string {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return string(body)
+	}
+
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			}
+		}
+		if n.Type == html.TextNode {
+			sb.WriteString(strings.ReplaceAll(n.Data, "\n", " "))
+		}
+		block := n.Type == html.ElementNode && blockElements[n.Data]
+		if block {
+			sb.WriteByte('\n')
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block {
+			sb.WriteByte('\n')
+		}
+	}
+	walk(doc)
+	return sb.String()
+}
+
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
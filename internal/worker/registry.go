@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+type Checker interface {
+	Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus
+}
+
+type CheckerFunc func(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus
+
+func (f CheckerFunc) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
+	return f(ctx, url, opts)
+}
+
+// Registry dispatches checks by URL scheme. Third-party probes plug in by
+// calling Register at startup.
+type Registry struct {
+	mu       sync.RWMutex
+	checkers map[string]Checker
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checkers: make(map[string]Checker)}
+}
+
+func (r *Registry) Register(scheme string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers[strings.ToLower(scheme)] = checker
+}
+
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schemes := make([]string, 0, len(r.checkers))
+	for scheme := range r.checkers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+func (r *Registry) Check(ctx context.Context, rawURL string, opts models.CheckOptions) models.LinkStatus {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return models.LinkStatus{URL: rawURL, Status: models.StatusNotAvailable, Result: models.ResultInvalidURL, CheckTime: clock.Now(), Error: err.Error()}
+	}
+
+	r.mu.RLock()
+	checker, ok := r.checkers[strings.ToLower(parsed.Scheme)]
+	r.mu.RUnlock()
+	if !ok || parsed.Opaque != "" {
+		// Every checker needs a host; mailto:, tel: and other opaque URLs
+		// have none.
+		reason := "no checker registered for scheme " + parsed.Scheme
+		if ok {
+			reason = "scheme " + parsed.Scheme + " needs a host, as in " + parsed.Scheme + "://host"
+		}
+		return models.LinkStatus{
+			URL:       rawURL,
+			Status:    models.StatusUnsupportedScheme,
+			Result:    models.ResultInvalidURL,
+			CheckTime: clock.Now(),
+			Error:     reason,
+		}
+	}
+
+	return checker.Check(ctx, rawURL, opts)
+}
@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestRegistry_DispatchesByScheme(t *testing.T) {
+	t.Parallel()
+
+	wsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(buf, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+			websocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+		buf.Flush()
+	}))
+	defer wsServer.Close()
+
+	ftpAddr := serveLines(t, func(conn net.Conn) {
+		fmt.Fprint(conn, "220-Welcome to the mirror\r\n220 Ready\r\n")
+		bufio.NewReader(conn).ReadString('\n')
+		fmt.Fprint(conn, "221 Bye\r\n")
+	})
+
+	smtpAddr := serveLines(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 mx.example.com ESMTP\r\n")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				fmt.Fprint(conn, "250-mx.example.com\r\n250-SIZE 1000000\r\n250 8BITMIME\r\n")
+			case strings.HasPrefix(line, "QUIT"):
+				fmt.Fprint(conn, "221 Bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "502 Not implemented\r\n")
+			}
+		}
+	})
+
+	httpChecker := NewHTTPChecker(2 * time.Second)
+	registry := NewRegistry()
+	registry.Register("ws", CheckerFunc(httpChecker.CheckWebSocket))
+	registry.Register("ftp", NewFTPChecker(2*time.Second))
+	registry.Register("smtp", NewSMTPChecker(2*time.Second, "checker.local"))
+
+	tests := []struct {
+		url    string
+		status string
+		detail string
+	}{
+		{"ws" + strings.TrimPrefix(wsServer.URL, "http") + "/socket", models.StatusAvailable, ""},
+		{"ftp://" + ftpAddr, models.StatusAvailable, "Welcome to the mirror\nReady"},
+		{"smtp://" + smtpAddr, models.StatusAvailable, "none"},
+		{"gopher://example.com", models.StatusUnsupportedScheme, ""},
+		{"mailto:foo@example.com", models.StatusUnsupportedScheme, ""},
+		{"tel:+123", models.StatusUnsupportedScheme, ""},
+		{"javascript:void(0)", models.StatusUnsupportedScheme, ""},
+		{"smtp:mail.example.com:25", models.StatusUnsupportedScheme, ""},
+	}
+
+	for _, tt := range tests {
+		got := registry.Check(context.Background(), tt.url, models.CheckOptions{})
+		if got.Status != tt.status {
+			t.Fatalf("%s: got status %q (%s), want %q", tt.url, got.Status, got.Error, tt.status)
+		}
+		if tt.detail != "" && got.Details["banner"] != tt.detail && got.Details["tls"] != tt.detail {
+			t.Fatalf("%s: unexpected details %v", tt.url, got.Details)
+		}
+	}
+}
+
+func serveLines(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
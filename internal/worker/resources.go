@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/whiterage/14-11-2025/pkg/htmllinks"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+const (
+	maxPageResources    = 200
+	resourceConcurrency = 6
+)
+
+// checkResources loads every script, stylesheet, image and other embedded
+// resource of an HTML page. Task headers and auth are only sent to the
+// page's own origin so credentials never leak to third-party hosts.
+func (c *HTTPChecker) checkResources(ctx context.Context, page *url.URL, body []byte, opts models.CheckOptions) *models.ResourceReport {
+	links, err := htmllinks.Extract(page, bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	report := &models.ResourceReport{}
+	seen := make(map[string]bool)
+	var resources []htmllinks.Link
+	for _, link := range links {
+		if !link.Resource || seen[link.URL] {
+			continue
+		}
+		seen[link.URL] = true
+		if len(resources) == maxPageResources {
+			report.Truncated = true
+			break
+		}
+		resources = append(resources, link)
+	}
+	report.Total = len(resources)
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, resourceConcurrency)
+	)
+	for _, res := range resources {
+		if page.Scheme == "https" && isPlainHTTP(res.URL) {
+			mu.Lock()
+			report.MixedContent = append(report.MixedContent, res.URL)
+			mu.Unlock()
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(res htmllinks.Link) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if failure, ok := c.fetchResource(ctx, page, res, opts); !ok {
+				mu.Lock()
+				report.Broken = append(report.Broken, failure)
+				mu.Unlock()
+			}
+		}(res)
+	}
+	wg.Wait()
+
+	// A resource can be both mixed content and broken; count it once.
+	failed := make(map[string]struct{}, len(report.Broken)+len(report.MixedContent))
+	for _, failure := range report.Broken {
+		failed[failure.URL] = struct{}{}
+	}
+	for _, mixed := range report.MixedContent {
+		failed[mixed] = struct{}{}
+	}
+	report.Failed = len(failed)
+	return report
+}
+
+func (c *HTTPChecker) fetchResource(ctx context.Context, page *url.URL, res htmllinks.Link, opts models.CheckOptions) (models.ResourceFailure, bool) {
+	failure := models.ResourceFailure{URL: res.URL, Tag: res.Tag}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, res.URL, nil)
+	if err != nil {
+		failure.Error = err.Error()
+		return failure, false
+	}
+	// A resource robots.txt keeps us away from is skipped, not broken.
+	if err := c.admit(ctx, req); errors.Is(err, errRobotsDisallowed) {
+		return failure, true
+	} else if err != nil {
+		failure.Error = err.Error()
+		return failure, false
+	}
+
+	resourceOpts := models.CheckOptions{}
+	if req.URL.Scheme == page.Scheme && req.URL.Host == page.Host {
+		resourceOpts = opts
+	}
+	if err := c.prepareRequest(req, resourceOpts); err != nil {
+		failure.Error = err.Error()
+		return failure, false
+	}
+
+	client := c.client
+	if len(resourceOpts.Headers) > 0 || resourceOpts.Auth != nil {
+		client = sameOriginCredentials(c.client, page, resourceOpts)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		failure.Error = err.Error()
+		return failure, false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxPageSize))
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		failure.StatusCode = resp.StatusCode
+		failure.Error = fmt.Sprintf("status %d", resp.StatusCode)
+		return failure, false
+	}
+	return failure, true
+}
+
+// sameOriginCredentials returns a copy of client that drops the task headers
+// and auth when a resource redirects away from the page's origin.
+func sameOriginCredentials(client *http.Client, page *url.URL, opts models.CheckOptions) *http.Client {
+	copied := *client
+	copied.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if req.URL.Scheme != page.Scheme || req.URL.Host != page.Host {
+			for _, h := range opts.Headers {
+				req.Header.Del(h.Name)
+			}
+			req.Header.Del("Authorization")
+		}
+		return nil
+	}
+	return &copied
+}
+
+func isPlainHTTP(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && parsed.Scheme == "http"
+}
@@ -0,0 +1,145 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestHTTPChecker_CheckResources(t *testing.T) {
+	t.Parallel()
+
+	var cdnAuth string
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cdnAuth = r.Header.Get("X-Token")
+		if r.URL.Path == "/missing.js" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer cdn.Close()
+
+	var assetAuth string
+	site := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintf(w, `<html><head>
+<link rel="stylesheet" href="/site.css">
+<link rel="canonical" href="/canonical">
+<script src="%[1]s/missing.js"></script>
+</head><body>
+<img src="/broken.png"><img src="/site.css">
+<a href="/elsewhere">link</a>
+</body></html>`, cdn.URL)
+		case "/plain":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><img src="/site.css"></body></html>`)
+		case "/site.css":
+			assetAuth = r.Header.Get("X-Token")
+			fmt.Fprint(w, "body{}")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	checker := NewHTTPChecker(2 * time.Second)
+	checker.client = site.Client()
+	checker.client.Timeout = 2 * time.Second
+
+	opts := models.CheckOptions{
+		CheckResources: true,
+		Headers:        []models.Header{{Name: "X-Token", Value: "abc"}},
+	}
+	result := checker.Check(context.Background(), site.URL+"/", opts)
+	if result.Status != models.StatusBrokenResources {
+		t.Fatalf("status = %s, want %s (%s)", result.Status, models.StatusBrokenResources, result.Error)
+	}
+
+	report := result.Resources
+	if report == nil || report.Total != 3 || report.Failed != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	var broken []string
+	for _, failure := range report.Broken {
+		broken = append(broken, failure.URL)
+	}
+	sort.Strings(broken)
+	want := []string{cdn.URL + "/missing.js", site.URL + "/broken.png"}
+	sort.Strings(want)
+	if fmt.Sprint(broken) != fmt.Sprint(want) {
+		t.Fatalf("broken = %v, want %v", broken, want)
+	}
+	if len(report.MixedContent) != 1 || report.MixedContent[0] != cdn.URL+"/missing.js" {
+		t.Fatalf("mixed content = %v", report.MixedContent)
+	}
+	if assetAuth != "abc" || cdnAuth != "" {
+		t.Fatalf("task headers leaked or missing: same-origin %q, third-party %q", assetAuth, cdnAuth)
+	}
+
+	result = checker.Check(context.Background(), site.URL+"/plain", opts)
+	if result.Status != models.StatusAvailable || result.Resources == nil || result.Resources.Failed != 0 {
+		t.Fatalf("unexpected result for healthy page: %s %+v", result.Status, result.Resources)
+	}
+}
+
+func TestHTTPChecker_ResourceRedirectDropsCredentials(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		leaked   []string
+		received bool
+	)
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = true
+		for _, name := range []string{"X-Api-Key", "Authorization"} {
+			if value := r.Header.Get(name); value != "" {
+				leaked = append(leaked, name+": "+value)
+			}
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer cdn.Close()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><img src="/logo.png"></body></html>`)
+		case "/logo.png":
+			http.Redirect(w, r, cdn.URL+"/logo.png", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	checker := NewHTTPChecker(2*time.Second, WithSecrets(staticSecrets{"api_key": "key-7", "token": "tok-42"}))
+	opts := models.CheckOptions{
+		CheckResources: true,
+		Headers:        []models.Header{{Name: "X-Api-Key", Secret: "api_key"}},
+		Auth:           &models.Auth{Type: models.AuthBearer, Secret: "token"},
+	}
+	result := checker.Check(context.Background(), site.URL+"/", opts)
+	if result.Resources == nil || result.Resources.Failed != 0 {
+		t.Fatalf("unexpected resources: %+v (%s)", result.Resources, result.Error)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !received || len(leaked) > 0 {
+		t.Fatalf("redirected resource got credentials: received %v, leaked %v", received, leaked)
+	}
+}
@@ -0,0 +1,260 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const robotsMaxSize = 512 << 10
+
+// robotsRetry is how long an unreachable robots.txt (a server or network
+// error) keeps the host disallowed before it is fetched again.
+const robotsRetry = time.Minute
+
+type robotsRule struct {
+	allow bool
+	path  string
+}
+
+type RobotsRules struct {
+	rules      []robotsRule
+	CrawlDelay time.Duration
+}
+
+// Allowed applies the longest matching rule; on a tie Allow wins.
+func (r *RobotsRules) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	best := -1
+	allowed := true
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.path, path) {
+			continue
+		}
+		if len(rule.path) > best || (len(rule.path) == best && rule.allow) {
+			best = len(rule.path)
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	if anchored {
+		return pos == len(path)
+	}
+	return true
+}
+
+// ParseRobots picks the groups addressed to agent, the longest user-agent
+// value that prefixes its product token winning, and falls back to the
+// wildcard groups. Groups naming the same agent are merged (RFC 9309).
+func ParseRobots(r io.Reader, agent string) *RobotsRules {
+	token := strings.ToLower(agent)
+	if idx := strings.IndexAny(token, "/ "); idx >= 0 {
+		token = token[:idx]
+	}
+
+	type group struct {
+		agents []string
+		rules  RobotsRules
+	}
+
+	var (
+		groups   []*group
+		current  *group
+		inAgents bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules.rules = append(current.rules.rules, robotsRule{allow: key == "allow", path: value})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.rules.CrawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	best := ""
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a != "*" && token != "" && strings.HasPrefix(token, a) && len(a) > len(best) {
+				best = a
+			}
+		}
+	}
+	if best == "" {
+		best = "*"
+	}
+
+	merged := &RobotsRules{}
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a != best {
+				continue
+			}
+			merged.rules = append(merged.rules, g.rules.rules...)
+			merged.CrawlDelay = max(merged.CrawlDelay, g.rules.CrawlDelay)
+			break
+		}
+	}
+	return merged
+}
+
+type robotsEntry struct {
+	rules     *RobotsRules
+	expiresAt time.Time
+}
+
+// robotsFlight is a robots.txt download shared by every check of the origin
+// that needs it meanwhile.
+type robotsFlight struct {
+	done  chan struct{}
+	rules *RobotsRules
+}
+
+type RobotsCache struct {
+	client    *http.Client
+	userAgent string
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[string]robotsEntry
+	flights   map[string]*robotsFlight
+}
+
+func NewRobotsCache(client *http.Client, userAgent string, ttl time.Duration) *RobotsCache {
+	return &RobotsCache{
+		client:    client,
+		userAgent: userAgent,
+		ttl:       ttl,
+		entries:   make(map[string]robotsEntry),
+		flights:   make(map[string]*robotsFlight),
+	}
+}
+
+// Rules returns the rules for the origin of target, downloading robots.txt
+// once for all concurrent callers. The download does not inherit the
+// caller's cancellation, so one cancelled task cannot decide the rules for
+// the others; the caller only stops waiting.
+func (c *RobotsCache) Rules(ctx context.Context, target *url.URL) (*RobotsRules, error) {
+	origin := target.Scheme + "://" + target.Host
+
+	c.mu.Lock()
+	if entry, ok := c.entries[origin]; ok && time.Now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.rules, nil
+	}
+	f, ok := c.flights[origin]
+	if !ok {
+		f = &robotsFlight{done: make(chan struct{})}
+		c.flights[origin] = f
+		go c.load(context.WithoutCancel(ctx), origin, f)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.rules, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *RobotsCache) load(ctx context.Context, origin string, f *robotsFlight) {
+	rules, ttl := c.fetch(ctx, origin)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[origin] = robotsEntry{rules: rules, expiresAt: time.Now().Add(ttl)}
+	delete(c.flights, origin)
+	f.rules = rules
+	close(f.done)
+}
+
+// fetch treats a missing robots.txt as "allow everything" and an unreachable
+// one, a server or network error, as "disallow everything" until it is
+// retried (RFC 9309).
+func (c *RobotsCache) fetch(ctx context.Context, origin string) (*RobotsRules, time.Duration) {
+	disallowAll := &RobotsRules{rules: []robotsRule{{allow: false, path: "/"}}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return &RobotsRules{}, c.ttl
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return disallowAll, min(c.ttl, robotsRetry)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return disallowAll, min(c.ttl, robotsRetry)
+	case resp.StatusCode != http.StatusOK:
+		return &RobotsRules{}, c.ttl
+	}
+	return ParseRobots(io.LimitReader(resp.Body, robotsMaxSize), c.userAgent), c.ttl
+}
@@ -0,0 +1,251 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestParseRobots(t *testing.T) {
+	t.Parallel()
+
+	const body = `
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Crawl-delay: 2
+
+User-agent: webserver-go-linkchecker
+Disallow: /tmp/
+Disallow: /*.pdf$
+Crawl-delay: 0.5
+`
+
+	rules := ParseRobots(strings.NewReader(body), DefaultUserAgent)
+	if rules.CrawlDelay != 500*time.Millisecond {
+		t.Fatalf("unexpected crawl delay: %v", rules.CrawlDelay)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/private", true},
+		{"/tmp/file", false},
+		{"/docs/guide.pdf", false},
+		{"/docs/guide.pdf?x=1", true},
+	}
+	for _, tt := range tests {
+		if got := rules.Allowed(tt.path); got != tt.want {
+			t.Fatalf("Allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	fallback := ParseRobots(strings.NewReader(body), "other-bot/2.0")
+	if fallback.Allowed("/private/data") {
+		t.Fatalf("wildcard group should disallow /private/data")
+	}
+	if !fallback.Allowed("/private/public/page") {
+		t.Fatalf("longer allow rule should win")
+	}
+	if fallback.CrawlDelay != 2*time.Second {
+		t.Fatalf("unexpected fallback crawl delay: %v", fallback.CrawlDelay)
+	}
+}
+
+func TestParseRobots_MostSpecificGroup(t *testing.T) {
+	t.Parallel()
+
+	const body = `
+User-agent: *
+Disallow: /
+
+User-agent: webserver
+Disallow: /generic
+
+User-agent: webserver-go
+Disallow: /first
+Crawl-delay: 1
+
+User-agent: other
+Disallow: /other
+
+User-agent: WebServer-Go
+Disallow: /second
+Crawl-delay: 3
+`
+
+	rules := ParseRobots(strings.NewReader(body), DefaultUserAgent)
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/generic", true},
+		{"/first", false},
+		{"/second", false},
+		{"/other", true},
+	}
+	for _, tt := range tests {
+		if got := rules.Allowed(tt.path); got != tt.want {
+			t.Fatalf("Allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if rules.CrawlDelay != 3*time.Second {
+		t.Fatalf("unexpected crawl delay: %v", rules.CrawlDelay)
+	}
+
+	if ParseRobots(strings.NewReader(body), "mywebserver-go/1.0").Allowed("/page") {
+		t.Fatalf("an agent named inside another token should fall back to the wildcard group")
+	}
+}
+
+func TestRobotsCache_StatusCodes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		status = http.StatusServiceUnavailable
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL + "/page")
+	cache := NewRobotsCache(srv.Client(), DefaultUserAgent, time.Hour)
+	if mustRules(t, cache, target).Allowed("/page") {
+		t.Fatalf("a 5xx robots.txt must disallow the host")
+	}
+
+	mu.Lock()
+	status = http.StatusNotFound
+	mu.Unlock()
+	missing := NewRobotsCache(srv.Client(), DefaultUserAgent, time.Hour)
+	if !mustRules(t, missing, target).Allowed("/page") {
+		t.Fatalf("a missing robots.txt must allow the host")
+	}
+
+	srv.Close()
+	unreachable := NewRobotsCache(srv.Client(), DefaultUserAgent, time.Hour)
+	if mustRules(t, unreachable, target).Allowed("/page") {
+		t.Fatalf("an unreachable robots.txt must disallow the host")
+	}
+}
+
+func TestRobotsCache_SharesFetchAndIgnoresCancelledCallers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		<-release
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL + "/page")
+	cache := NewRobotsCache(srv.Client(), DefaultUserAgent, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := cache.Rules(ctx, target)
+		cancelled <- err
+	}()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rules := mustRules(t, cache, target); rules.Allowed("/private") || !rules.Allowed("/page") {
+				t.Errorf("unexpected rules from the shared fetch")
+			}
+		}()
+	}
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled caller to get context.Canceled, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	mustRules(t, cache, target)
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 {
+		t.Fatalf("expected one robots.txt request, got %d", requests)
+	}
+}
+
+func mustRules(t *testing.T, cache *RobotsCache, target *url.URL) *RobotsRules {
+	t.Helper()
+	rules, err := cache.Rules(context.Background(), target)
+	if err != nil {
+		t.Errorf("Rules: %v", err)
+		return &RobotsRules{}
+	}
+	return rules
+}
+
+func TestHTTPChecker_SkipsDisallowedLinks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		visited []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		mu.Lock()
+		visited = append(visited, r.URL.Path)
+		mu.Unlock()
+		w.Write([]byte("<html><a href=\"/next\">next</a></html>"))
+	}))
+	defer srv.Close()
+
+	checker := NewHTTPChecker(2*time.Second, WithRobots(time.Hour))
+
+	skipped := checker.Check(context.Background(), srv.URL+"/private/page", models.CheckOptions{})
+	if skipped.Status != models.StatusSkippedRobots || skipped.Result != models.ResultRobotsDisallowed || skipped.CheckTime.IsZero() {
+		t.Fatalf("unexpected skipped result: %+v", skipped)
+	}
+	if models.AvailabilityOf(skipped.Status) != models.StatusSkippedRobots {
+		t.Fatalf("skipped link reported as %q", models.AvailabilityOf(skipped.Status))
+	}
+	if _, err := checker.Fetch(context.Background(), srv.URL+"/private/page", models.CheckOptions{}); err == nil {
+		t.Fatalf("disallowed page fetched")
+	}
+	allowed := checker.Check(context.Background(), srv.URL+"/public", models.CheckOptions{KeepPage: true})
+	if allowed.Status != models.StatusAvailable || allowed.Page == nil || !strings.Contains(string(allowed.Page.Body), "/next") {
+		t.Fatalf("allowed link not checked or page not kept: %+v", allowed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(visited) != 1 || visited[0] != "/public" {
+		t.Fatalf("unexpected requests: %v", visited)
+	}
+}
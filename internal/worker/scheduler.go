@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type HostScheduler struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func NewHostScheduler() *HostScheduler {
+	return &HostScheduler{next: make(map[string]time.Time)}
+}
+
+// Wait reserves the next slot for host so that consecutive requests to it
+// are at least delay apart, and blocks until that slot arrives.
+func (s *HostScheduler) Wait(ctx context.Context, host string, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	slot := s.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	s.next[host] = slot.Add(delay)
+	s.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
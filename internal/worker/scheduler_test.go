@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostScheduler_SpacesRequestsPerHost(t *testing.T) {
+	t.Parallel()
+
+	s := NewHostScheduler()
+	const delay = 40 * time.Millisecond
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := s.Wait(context.Background(), "a.example", delay); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*delay {
+		t.Fatalf("three requests took %v, want at least %v", elapsed, 2*delay)
+	}
+
+	other := time.Now()
+	if err := s.Wait(context.Background(), "b.example", delay); err != nil || time.Since(other) > delay/2 {
+		t.Fatalf("another host should not wait: %v after %v", err, time.Since(other))
+	}
+	if err := s.Wait(context.Background(), "c.example", 0); err != nil {
+		t.Fatalf("zero delay: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(ctx, "a.example", time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("cancelled wait returned %v", err)
+	}
+}
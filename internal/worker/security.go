@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+const minHSTSMaxAge = 180 * 24 * 60 * 60
+
+var auditedHeaders = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"Referrer-Policy",
+}
+
+// auditSecurity records security-relevant response headers and cookie flags
+// and grades the response from A to F. Cookie values are never recorded.
+func auditSecurity(resp *http.Response) *models.SecurityReport {
+	report := &models.SecurityReport{
+		Score:   100,
+		Headers: make(map[string]string),
+	}
+	penalize := func(points int, finding string) {
+		report.Score -= points
+		report.Findings = append(report.Findings, finding)
+	}
+
+	for _, name := range auditedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			report.Headers[name] = value
+		}
+	}
+
+	https := resp.Request != nil && resp.Request.URL.Scheme == "https"
+	if !https {
+		penalize(20, "page is served over plain HTTP")
+	} else if hsts, ok := report.Headers["Strict-Transport-Security"]; !ok {
+		penalize(20, "Strict-Transport-Security is missing")
+	} else if maxAge := hstsMaxAge(hsts); maxAge < minHSTSMaxAge {
+		penalize(10, fmt.Sprintf("Strict-Transport-Security max-age is %d, below 180 days", maxAge))
+	}
+
+	csp, hasCSP := report.Headers["Content-Security-Policy"]
+	switch {
+	case !hasCSP:
+		penalize(25, "Content-Security-Policy is missing")
+	case strings.Contains(csp, "'unsafe-inline'") || strings.Contains(csp, "'unsafe-eval'"):
+		penalize(10, "Content-Security-Policy allows unsafe-inline or unsafe-eval")
+	}
+
+	if _, ok := report.Headers["X-Frame-Options"]; !ok && !strings.Contains(csp, "frame-ancestors") {
+		penalize(15, "clickjacking protection is missing (X-Frame-Options or CSP frame-ancestors)")
+	}
+	if !strings.EqualFold(report.Headers["X-Content-Type-Options"], "nosniff") {
+		penalize(10, "X-Content-Type-Options: nosniff is missing")
+	}
+	if _, ok := report.Headers["Referrer-Policy"]; !ok {
+		penalize(10, "Referrer-Policy is missing")
+	}
+
+	for _, cookie := range resp.Cookies() {
+		audit := models.CookieAudit{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+		}
+		report.Cookies = append(report.Cookies, audit)
+
+		if !audit.Secure && https {
+			penalize(5, fmt.Sprintf("cookie %q lacks the Secure flag", cookie.Name))
+		}
+		if !audit.HttpOnly {
+			penalize(5, fmt.Sprintf("cookie %q lacks the HttpOnly flag", cookie.Name))
+		}
+		if audit.SameSite == "" {
+			penalize(5, fmt.Sprintf("cookie %q has no SameSite attribute", cookie.Name))
+		}
+	}
+
+	if report.Score < 0 {
+		report.Score = 0
+	}
+	report.Grade = securityGrade(report.Score)
+	return report
+}
+
+func hstsMaxAge(value string) int {
+	for _, directive := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if ok && strings.EqualFold(key, "max-age") {
+			age, err := strconv.Atoi(strings.Trim(val, `"`))
+			if err == nil {
+				return age
+			}
+		}
+	}
+	return 0
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
+
+func securityGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	}
+	return "F"
+}
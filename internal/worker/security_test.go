@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAuditSecurity(t *testing.T) {
+	t.Parallel()
+
+	target, _ := url.Parse("https://example.com/")
+
+	hardened := &http.Response{
+		Request: &http.Request{URL: target},
+		Header: http.Header{
+			"Strict-Transport-Security": {"max-age=31536000; includeSubDomains"},
+			"Content-Security-Policy":   {"default-src 'self'; frame-ancestors 'none'"},
+			"X-Content-Type-Options":    {"nosniff"},
+			"Referrer-Policy":           {"strict-origin-when-cross-origin"},
+			"Set-Cookie":                {"session=abc; Secure; HttpOnly; SameSite=Lax"},
+		},
+	}
+	report := auditSecurity(hardened)
+	if report.Grade != "A" || len(report.Findings) != 0 {
+		t.Fatalf("unexpected report for hardened response: %+v", report)
+	}
+	if len(report.Cookies) != 1 || !report.Cookies[0].Secure || report.Cookies[0].SameSite != "Lax" {
+		t.Fatalf("unexpected cookie audit: %+v", report.Cookies)
+	}
+
+	bare := &http.Response{
+		Request: &http.Request{URL: target},
+		Header: http.Header{
+			"Strict-Transport-Security": {"max-age=300"},
+			"Set-Cookie":                {"tracking=1"},
+		},
+	}
+	report = auditSecurity(bare)
+	if report.Grade != "F" {
+		t.Fatalf("expected grade F, got %s (%d): %v", report.Grade, report.Score, report.Findings)
+	}
+	if report.Headers["Strict-Transport-Security"] != "max-age=300" {
+		t.Fatalf("header value not recorded: %v", report.Headers)
+	}
+}
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// SMTPChecker opens an SMTP session, sends EHLO and, when the server
+// advertises STARTTLS, verifies that the TLS upgrade actually succeeds.
+// smtps:// URLs use implicit TLS.
+type SMTPChecker struct {
+	timeout   time.Duration
+	localName string
+}
+
+func NewSMTPChecker(timeout time.Duration, localName string) *SMTPChecker {
+	if localName == "" {
+		localName = "localhost"
+	}
+	return &SMTPChecker{timeout: timeout, localName: localName}
+}
+
+func (c *SMTPChecker) Check(ctx context.Context, rawURL string, opts models.CheckOptions) models.LinkStatus {
+	fail := func(err error) models.LinkStatus {
+		return models.LinkStatus{URL: rawURL, Status: models.StatusNotAvailable, Result: classifyError(err), CheckTime: clock.Now(), Error: err.Error()}
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fail(err)
+	}
+	implicitTLS := parsed.Scheme == "smtps"
+	port := "25"
+	if implicitTLS {
+		port = "465"
+	}
+	addr := hostPort(parsed, port)
+
+	start := time.Now()
+	conn, err := dialTimeout(ctx, addr, c.timeout)
+	if err != nil {
+		return fail(err)
+	}
+	if implicitTLS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: parsed.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fail(fmt.Errorf("tls handshake: %w", err))
+		}
+		conn = tlsConn
+	}
+
+	client, err := smtp.NewClient(conn, parsed.Hostname())
+	if err != nil {
+		conn.Close()
+		return fail(err)
+	}
+	defer client.Close()
+
+	if err := client.Hello(c.localName); err != nil {
+		return fail(fmt.Errorf("ehlo: %w", err))
+	}
+
+	details := map[string]string{"tls": "none"}
+	if implicitTLS {
+		details["tls"] = "implicit"
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok && !implicitTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: parsed.Hostname()}); err != nil {
+			return fail(fmt.Errorf("starttls: %w", err))
+		}
+		details["tls"] = "starttls"
+	}
+
+	var extensions []string
+	for _, ext := range []string{"SIZE", "8BITMIME", "PIPELINING", "AUTH", "SMTPUTF8"} {
+		if ok, _ := client.Extension(ext); ok {
+			extensions = append(extensions, ext)
+		}
+	}
+	if len(extensions) > 0 {
+		details["extensions"] = strings.Join(extensions, " ")
+	}
+	_ = client.Quit()
+
+	return models.LinkStatus{
+		URL:       rawURL,
+		Status:    models.StatusAvailable,
+		Result:    models.ResultOK,
+		CheckTime: clock.Now(),
+		Timing:    &models.Timing{Total: millis(start, time.Now())},
+		Details:   details,
+	}
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+func dialTimeout(ctx context.Context, addr string, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	return conn, nil
+}
@@ -0,0 +1,205 @@
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+const (
+	maxInspectSize    = 256 << 10
+	probeTTL          = time.Hour
+	softSimilarity    = 0.9
+	shortPageTextSize = 2048
+)
+
+var (
+	notFoundTitle = regexp.MustCompile(`(?i)\b404\b|not found|page (?:does not|doesn't) exist|страница не найдена|не найден`)
+	notFoundBody  = regexp.MustCompile(`(?i)page not found|page (?:does not|doesn't) exist|no longer available|страница не найдена`)
+
+	wafMarkers = []string{
+		"cf-chl-",
+		"challenge-platform",
+		"attention required! | cloudflare",
+		"just a moment...",
+		"g-recaptcha",
+		"h-captcha",
+		"hcaptcha.com",
+		"_incapsula_resource",
+		"incapsula incident id",
+		"the requested url was rejected. please consult with your administrator",
+		"sucuri website firewall",
+		"ddos-guard",
+		"checking your browser before accessing",
+		"reference&#32;&#35;",
+	}
+)
+
+type pageProbe struct {
+	status    int
+	finalURL  string
+	words     map[string]struct{}
+	fetchedAt time.Time
+}
+
+type probeCache struct {
+	mu      sync.Mutex
+	entries map[string]pageProbe
+}
+
+func newProbeCache() *probeCache {
+	return &probeCache{entries: make(map[string]pageProbe)}
+}
+
+// detectWAF recognises bot-challenge and captcha interstitials served by
+// the common CDN/WAF vendors. A successful response only counts when the page
+// has next to no visible text, so regular pages embedding a captcha widget
+// or vendor scripts are not flagged.
+func detectWAF(resp *http.Response, body []byte) bool {
+	if resp.Header.Get("Cf-Mitigated") == "challenge" || resp.Header.Get("X-Amzn-Waf-Action") != "" {
+		return true
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		if _, text := pageText(body); len(text) >= shortPageTextSize {
+			return false
+		}
+	}
+
+	lower := strings.ToLower(string(body))
+	for _, marker := range wafMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectSoft404 compares a 2xx page with what the host returns for a path
+// that certainly does not exist, and falls back to title/body patterns.
+func (c *HTTPChecker) detectSoft404(ctx context.Context, resp *http.Response, body []byte, opts models.CheckOptions) bool {
+	title, text := pageText(body)
+	if notFoundTitle.MatchString(title) {
+		return true
+	}
+	if len(text) < shortPageTextSize && notFoundBody.MatchString(text) {
+		return true
+	}
+
+	probe, ok := c.probeHost(ctx, resp.Request.URL, opts)
+	if !ok || probe.status < http.StatusOK || probe.status >= http.StatusMultipleChoices {
+		return false
+	}
+	// The host redirects unknown paths to this page. Only a page redirected
+	// away from a non-root path is missing; the landing page itself, or a
+	// root URL moved to https, is not.
+	if final := pageKey(resp.Request.URL); probe.finalURL == final {
+		checked := originalURL(resp)
+		return pageKey(checked) != final && !isRootPath(checked.Path)
+	}
+
+	if !isRootPath(resp.Request.URL.Path) {
+		text = strings.ReplaceAll(text, resp.Request.URL.Path, " ")
+	}
+	return jaccard(wordSet(text), probe.words) >= softSimilarity
+}
+
+// originalURL returns the URL requested before any redirect.
+func originalURL(resp *http.Response) *url.URL {
+	req := resp.Request
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	return req.URL
+}
+
+// pageKey identifies a page for comparison; an empty path is the root.
+func pageKey(u *url.URL) string {
+	page := *u
+	page.Fragment = ""
+	if page.Path == "" {
+		page.Path = "/"
+	}
+	return page.String()
+}
+
+func isRootPath(path string) bool {
+	return path == "" || path == "/"
+}
+
+func (c *HTTPChecker) probeHost(ctx context.Context, target *url.URL, opts models.CheckOptions) (pageProbe, bool) {
+	origin := target.Scheme + "://" + target.Host
+
+	c.probes.mu.Lock()
+	probe, ok := c.probes.entries[origin]
+	c.probes.mu.Unlock()
+	if ok && time.Since(probe.fetchedAt) < probeTTL {
+		return probe, true
+	}
+
+	token := make([]byte, 12)
+	if _, err := rand.Read(token); err != nil {
+		return pageProbe{}, false
+	}
+	probePath := "/" + hex.EncodeToString(token) + "-does-not-exist"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+probePath, nil)
+	if err != nil {
+		return pageProbe{}, false
+	}
+	if err := c.admit(ctx, req); err != nil {
+		return pageProbe{}, false
+	}
+	if err := c.prepareRequest(req, opts); err != nil {
+		return pageProbe{}, false
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return pageProbe{}, false
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxInspectSize))
+
+	_, text := pageText(body)
+	probe = pageProbe{
+		status:    resp.StatusCode,
+		finalURL:  pageKey(resp.Request.URL),
+		words:     wordSet(strings.ReplaceAll(text, probePath, " ")),
+		fetchedAt: time.Now(),
+	}
+
+	c.probes.mu.Lock()
+	c.probes.entries[origin] = probe
+	c.probes.mu.Unlock()
+	return probe, true
+}
+
+func wordSet(text string) map[string]struct{} {
+	words := make(map[string]struct{})
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		words[word] = struct{}{}
+	}
+	return words
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	common := 0
+	for word := range a {
+		if _, ok := b[word]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestHTTPChecker_SoftPages(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><title>Docs</title><body>Welcome to the documentation portal with many guides.</body></html>`)
+		case "/gone":
+			fmt.Fprint(w, `<html><title>Oops</title><body>We could not find the page you requested.</body></html>`)
+		case "/title":
+			fmt.Fprint(w, `<html><title>404 Not Found</title><body>Sorry.</body></html>`)
+		case "/challenge":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<html><title>Just a moment...</title><script src="/cdn-cgi/challenge-platform/h/b/orchestrate/chl_page/v1?ray=1"></script></html>`)
+		default:
+			fmt.Fprintf(w, `<html><title>Oops</title><body>We could not find the page %s you requested.</body></html>`, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	checker := NewHTTPChecker(2 * time.Second)
+	opts := models.CheckOptions{DetectSoft404: true}
+
+	tests := []struct {
+		path string
+		opts models.CheckOptions
+		want string
+	}{
+		{"/", opts, models.StatusAvailable},
+		{"/gone", opts, models.StatusSoft404},
+		{"/title", opts, models.StatusSoft404},
+		{"/gone", models.CheckOptions{}, models.StatusAvailable},
+		{"/challenge", models.CheckOptions{}, models.StatusBlockedByWAF},
+	}
+	for _, tt := range tests {
+		got := checker.Check(context.Background(), srv.URL+tt.path, tt.opts)
+		if got.Status != tt.want {
+			t.Fatalf("%s: got status %q want %q", tt.path, got.Status, tt.want)
+		}
+	}
+}
+
+func TestHTTPChecker_SoftPagesRedirectingHome(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><title>Shop</title><body>Spring sale on every product in the catalogue.</body></html>`)
+		default:
+			http.Redirect(w, r, "/", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	checker := NewHTTPChecker(2 * time.Second)
+	opts := models.CheckOptions{DetectSoft404: true}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", models.StatusAvailable},
+		{"", models.StatusAvailable},
+		{"/missing-product", models.StatusSoft404},
+	}
+	for _, tt := range tests {
+		got := checker.Check(context.Background(), srv.URL+tt.path, opts)
+		if got.Status != tt.want {
+			t.Fatalf("%q: got status %q want %q", tt.path, got.Status, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"crypto/tls"
+	"math"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+// phaseTimer collects request phase timestamps from httptrace hooks. Hooks
+// may fire from dialer goroutines, hence the mutex. On redirects the later
+// hops overwrite earlier ones, so phases describe the final request.
+type phaseTimer struct {
+	mu        sync.Mutex
+	start     time.Time
+	dnsStart  time.Time
+	dnsDone   time.Time
+	connStart time.Time
+	connDone  time.Time
+	tlsStart  time.Time
+	tlsDone   time.Time
+	firstByte time.Time
+	reused    bool
+}
+
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{start: time.Now()}
+}
+
+func (t *phaseTimer) trace() *httptrace.ClientTrace {
+	mark := func(field *time.Time) {
+		t.mu.Lock()
+		*field = time.Now()
+		t.mu.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { mark(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			if t.connStart.IsZero() || !t.connDone.IsZero() {
+				t.connStart = time.Now()
+				t.connDone = time.Time{}
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				mark(&t.connDone)
+			}
+		},
+		TLSHandshakeStart: func() { mark(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { mark(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() { mark(&t.firstByte) },
+	}
+}
+
+func (t *phaseTimer) timing(end time.Time) *models.Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing := &models.Timing{
+		DNS:     millis(t.dnsStart, t.dnsDone),
+		Connect: millis(t.connStart, t.connDone),
+		TLS:     millis(t.tlsStart, t.tlsDone),
+		Total:   millis(t.start, end),
+		Reused:  t.reused,
+	}
+	if !t.firstByte.IsZero() {
+		timing.TTFB = millis(t.start, t.firstByte)
+		timing.Transfer = millis(t.firstByte, end)
+	}
+	return timing
+}
+
+func millis(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	ms := float64(to.Sub(from)) / float64(time.Millisecond)
+	return math.Round(ms*100) / 100
+}
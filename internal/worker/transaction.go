@@ -0,0 +1,283 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+var (
+	placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+	// bodyPlaceholderRe also matches {{secret:name}}, allowed in bodies only.
+	bodyPlaceholderRe = regexp.MustCompile(`\{\{\s*(secret:)?([A-Za-z0-9_.-]+)\s*\}\}`)
+)
+
+// TransactionChecker runs scripted multi-step checks (log in, follow the
+// session, assert on the protected page) with a cookie jar per check.
+// Links without a transaction are passed on to the wrapped HTTPChecker.
+type TransactionChecker struct {
+	http *HTTPChecker
+}
+
+func NewTransactionChecker(httpChecker *HTTPChecker) *TransactionChecker {
+	return &TransactionChecker{http: httpChecker}
+}
+
+func (c *TransactionChecker) Check(ctx context.Context, rawURL string, opts models.CheckOptions) models.LinkStatus {
+	if opts.Transaction == nil || len(opts.Transaction.Steps) == 0 {
+		return c.http.Check(ctx, rawURL, opts)
+	}
+
+	result := models.LinkStatus{URL: rawURL, Status: models.StatusNotAvailable}
+	fail := func(class models.ResultClass, err error) models.LinkStatus {
+		result.Result = class
+		result.Error = err.Error()
+		result.CheckTime = clock.Now()
+		return result
+	}
+
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return fail(models.ResultInvalidURL, err)
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return fail(models.ResultNetworkError, err)
+	}
+	client := &http.Client{
+		Transport: c.http.client.Transport,
+		Timeout:   c.http.client.Timeout,
+		Jar:       jar,
+	}
+
+	patterns := make(map[string]*regexp.Regexp)
+	for _, step := range opts.Transaction.Steps {
+		for _, capture := range step.Capture {
+			if capture.Regex == "" || patterns[capture.Regex] != nil {
+				continue
+			}
+			re, err := regexp.Compile(capture.Regex)
+			if err != nil {
+				return fail(models.ResultDegraded, fmt.Errorf("capture %q: %w", capture.Name, err))
+			}
+			patterns[capture.Regex] = re
+		}
+	}
+
+	vars := make(map[string]string)
+	var total float64
+	for i, step := range opts.Transaction.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+
+		stepResult, class, err := c.runStep(ctx, client, base, step, opts, vars, patterns)
+		stepResult.Name = name
+		total += stepResult.Duration
+		result.Steps = append(result.Steps, stepResult)
+		result.HTTPStatus = stepResult.StatusCode
+		result.Timing = &models.Timing{Total: total}
+		if err != nil {
+			result.Steps[i].Error = err.Error()
+			return fail(class, fmt.Errorf("%s failed: %w", name, err))
+		}
+	}
+
+	result.Status = models.StatusAvailable
+	result.Result = models.ResultOK
+	result.CheckTime = clock.Now()
+	return result
+}
+
+func (c *TransactionChecker) runStep(ctx context.Context, client *http.Client, base *url.URL, step models.Step, opts models.CheckOptions, vars map[string]string, patterns map[string]*regexp.Regexp) (models.StepResult, models.ResultClass, error) {
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = http.MethodGet
+		if len(step.Form) > 0 {
+			method = http.MethodPost
+		}
+	}
+	stepResult := models.StepResult{Method: method}
+
+	target, err := base.Parse(expand(step.URL, vars))
+	if err != nil {
+		return stepResult, models.ResultInvalidURL, err
+	}
+	// Queries may carry captured tokens, so only the path is reported.
+	display := *target
+	display.RawQuery = ""
+	stepResult.URL = display.String()
+
+	var body io.Reader
+	contentType := ""
+	switch {
+	case len(step.Form) > 0:
+		form := url.Values{}
+		for _, field := range step.Form {
+			value := expand(field.Value, vars)
+			if field.Secret != "" {
+				if value, err = c.http.lookupSecret(field.Secret); err != nil {
+					return stepResult, models.ResultConfigError, err
+				}
+			}
+			form.Add(field.Name, value)
+		}
+		body, contentType = strings.NewReader(form.Encode()), "application/x-www-form-urlencoded"
+	case step.Body != "":
+		text, err := c.expandBody(step.Body, vars)
+		if err != nil {
+			return stepResult, models.ResultConfigError, err
+		}
+		body = strings.NewReader(text)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return stepResult, models.ResultInvalidURL, err
+	}
+	if err := c.http.admit(ctx, req); errors.Is(err, errRobotsDisallowed) {
+		return stepResult, models.ResultRobotsDisallowed, err
+	} else if err != nil {
+		return stepResult, classifyError(err), err
+	}
+	stepOpts := opts
+	stepOpts.Headers = append(append([]models.Header(nil), opts.Headers...), expandHeaders(step.Headers, vars)...)
+	if err := c.http.prepareRequest(req, stepOpts); err != nil {
+		return stepResult, models.ResultConfigError, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		stepResult.Duration = millis(start, time.Now())
+		return stepResult, classifyError(err), err
+	}
+	defer resp.Body.Close()
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	stepResult.Duration = millis(start, time.Now())
+	stepResult.StatusCode = resp.StatusCode
+
+	if err := checkExpect(step.Expect, resp.StatusCode, string(content), vars); err != nil {
+		class := models.ResultDegraded
+		if resp.StatusCode >= http.StatusBadRequest {
+			class = classifyResponse(resp.StatusCode, false)
+		}
+		return stepResult, class, err
+	}
+
+	for _, capture := range step.Capture {
+		value, ok := captureValue(capture, patterns[capture.Regex], client.Jar, resp, content)
+		if !ok {
+			return stepResult, models.ResultDegraded, fmt.Errorf("capture %q: value not found", capture.Name)
+		}
+		vars[capture.Name] = value
+	}
+	return stepResult, models.ResultOK, nil
+}
+
+func checkExpect(expect *models.Expect, status int, body string, vars map[string]string) error {
+	if expect == nil || expect.Status == 0 {
+		if status >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status %d", status)
+		}
+	} else if status != expect.Status {
+		return fmt.Errorf("expected status %d, got %d", expect.Status, status)
+	}
+	if expect == nil {
+		return nil
+	}
+
+	for _, text := range expect.Contains {
+		if !strings.Contains(body, expand(text, vars)) {
+			return fmt.Errorf("response does not contain %q", text)
+		}
+	}
+	for _, text := range expect.NotContains {
+		if strings.Contains(body, expand(text, vars)) {
+			return fmt.Errorf("response contains %q", text)
+		}
+	}
+	return nil
+}
+
+func captureValue(capture models.Capture, re *regexp.Regexp, jar http.CookieJar, resp *http.Response, body []byte) (string, bool) {
+	switch {
+	case capture.Cookie != "":
+		for _, cookie := range jar.Cookies(resp.Request.URL) {
+			if cookie.Name == capture.Cookie {
+				return cookie.Value, true
+			}
+		}
+	case capture.Header != "":
+		value := resp.Header.Get(capture.Header)
+		return value, value != ""
+	case re != nil:
+		match := re.FindSubmatch(body)
+		switch {
+		case len(match) > 1:
+			return string(match[1]), true
+		case len(match) == 1:
+			return string(match[0]), true
+		}
+	}
+	return "", false
+}
+
+// expand replaces {{name}} with captured values; unknown names are kept so
+// a failing assertion shows what was missing.
+func expand(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return placeholderRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderRe.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// expandBody fills captures and {{secret:name}} references in one pass, so
+// a value taken from a response is never expanded into a secret.
+func (c *TransactionChecker) expandBody(text string, vars map[string]string) (string, error) {
+	var lookupErr error
+	expanded := bodyPlaceholderRe.ReplaceAllStringFunc(text, func(match string) string {
+		groups := bodyPlaceholderRe.FindStringSubmatch(match)
+		if groups[1] == "" {
+			if value, ok := vars[groups[2]]; ok {
+				return value
+			}
+			return match
+		}
+		value, err := c.http.lookupSecret(groups[2])
+		if err != nil && lookupErr == nil {
+			lookupErr = err
+		}
+		return value
+	})
+	return expanded, lookupErr
+}
+
+func expandHeaders(headers []models.Header, vars map[string]string) []models.Header {
+	expanded := make([]models.Header, len(headers))
+	for i, h := range headers {
+		h.Value = expand(h.Value, vars)
+		expanded[i] = h
+	}
+	return expanded
+}
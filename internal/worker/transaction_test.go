@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Lookup(name string) (string, bool) {
+	value, ok := s[name]
+	return value, ok
+}
+
+func TestTransactionChecker(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, `<form><input type="hidden" name="csrf" value="tok-42"></form>`)
+				return
+			}
+			if r.FormValue("csrf") != "tok-42" || r.FormValue("password") != "hunter2" {
+				http.Error(w, "bad login", http.StatusForbidden)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			http.Redirect(w, r, "/account", http.StatusFound)
+		case "/account":
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc" {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+			fmt.Fprint(w, "Welcome back, bot")
+		case "/api/echo":
+			fmt.Fprint(w, "{{secret:bot_password}}")
+		case "/api/login":
+			var creds struct{ Password, Token string }
+			if err := json.NewDecoder(r.Body).Decode(&creds); err != nil || creds.Password != "hunter2" || creds.Token != "{{secret:bot_password}}" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "ok")
+		case "/api/me":
+			if r.Header.Get("X-Session") != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"name":"bot"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	checker := NewTransactionChecker(NewHTTPChecker(2*time.Second, WithSecrets(staticSecrets{"bot_password": "hunter2"})))
+
+	login := []models.Step{
+		{Name: "login form", URL: "/login", Capture: []models.Capture{{Name: "csrf", Regex: `name="csrf" value="([^"]+)"`}}},
+		{
+			Name: "submit",
+			URL:  "/login",
+			Form: []models.FormField{
+				{Name: "csrf", Value: "{{csrf}}"},
+				{Name: "password", Secret: "bot_password"},
+			},
+			Capture: []models.Capture{{Name: "sid", Cookie: "session"}},
+			Expect:  &models.Expect{Contains: []string{"Welcome back"}},
+		},
+	}
+
+	steps := append(login, models.Step{
+		Name:    "api",
+		URL:     "/api/me",
+		Headers: []models.Header{{Name: "X-Session", Value: "{{sid}}"}},
+		Expect:  &models.Expect{Status: http.StatusOK, Contains: []string{`"bot"`}},
+	})
+	result := checker.Check(context.Background(), srv.URL, models.CheckOptions{Transaction: &models.Transaction{Steps: steps}})
+	if result.Status != models.StatusAvailable || len(result.Steps) != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Steps[1].Method != http.MethodPost || result.Timing == nil {
+		t.Fatalf("unexpected step report: %+v", result.Steps)
+	}
+
+	broken := append(login[:1:1], models.Step{
+		Name:   "dashboard",
+		URL:    "/account",
+		Expect: &models.Expect{Contains: []string{"Welcome back"}},
+	})
+	result = checker.Check(context.Background(), srv.URL, models.CheckOptions{Transaction: &models.Transaction{Steps: broken}})
+	if result.Status != models.StatusNotAvailable || len(result.Steps) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !strings.HasPrefix(result.Error, "dashboard failed") || result.Steps[1].Error == "" {
+		t.Fatalf("failed step not reported: %q %+v", result.Error, result.Steps)
+	}
+
+	// A captured value that looks like a secret reference is sent as is.
+	api := []models.Step{
+		{Name: "token", URL: "/api/echo", Capture: []models.Capture{{Name: "token", Regex: `(.+)`}}},
+		{Name: "login", URL: "/api/login", Method: http.MethodPost, Body: `{"password":"{{secret:bot_password}}","token":"{{token}}"}`},
+	}
+	result = checker.Check(context.Background(), srv.URL, models.CheckOptions{Transaction: &models.Transaction{Steps: api}})
+	if result.Status != models.StatusAvailable {
+		t.Fatalf("body secrets not expanded: %+v", result)
+	}
+
+	plain := checker.Check(context.Background(), srv.URL+"/account", models.CheckOptions{})
+	if plain.Status != models.StatusAvailable || plain.Steps != nil {
+		t.Fatalf("links without a transaction should use a plain check: %+v", plain)
+	}
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/clock"
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// CheckWebSocket performs the RFC 6455 opening handshake against a ws:// or
+// wss:// URL and closes the connection right after the upgrade.
+func (c *HTTPChecker) CheckWebSocket(ctx context.Context, rawURL string, opts models.CheckOptions) models.LinkStatus {
+	target := rawURL
+	switch {
+	case strings.HasPrefix(target, "wss://"):
+		target = "https://" + strings.TrimPrefix(target, "wss://")
+	case strings.HasPrefix(target, "ws://"):
+		target = "http://" + strings.TrimPrefix(target, "ws://")
+	}
+
+	fail := func(err error) models.LinkStatus {
+		return models.LinkStatus{URL: rawURL, Status: models.StatusNotAvailable, Result: classifyError(err), CheckTime: clock.Now(), Error: err.Error()}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fail(err)
+	}
+	if err := c.prepareRequest(req, opts); err != nil {
+		return fail(err)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fail(err)
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+	latency := millis(start, time.Now())
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		result := fail(fmt.Errorf("websocket upgrade rejected with status %d", resp.StatusCode))
+		result.HTTPStatus = resp.StatusCode
+		result.Result = models.ResultServerError
+		if resp.StatusCode >= http.StatusBadRequest {
+			result.Result = classifyResponse(resp.StatusCode, false)
+		}
+		return result
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		result := fail(fmt.Errorf("invalid Sec-WebSocket-Accept header"))
+		result.Result = models.ResultServerError
+		return result
+	}
+
+	details := map[string]string{}
+	if protocol := resp.Header.Get("Sec-WebSocket-Protocol"); protocol != "" {
+		details["protocol"] = protocol
+	}
+
+	return models.LinkStatus{
+		URL:       rawURL,
+		Status:    models.StatusAvailable,
+		Result:    models.ResultOK,
+		CheckTime: clock.Now(),
+		Timing:    &models.Timing{Total: latency},
+		Details:   details,
+	}
+}
+
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
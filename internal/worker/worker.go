@@ -2,41 +2,239 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/http/httptrace"
 	"time"
 
 	"github.com/whiterage/14-11-2025/pkg/clock"
 	"github.com/whiterage/14-11-2025/pkg/models"
 )
 
+type SecretLookup interface {
+	Lookup(name string) (string, bool)
+}
+
+const DefaultUserAgent = "webserver-go-linkchecker/1.0"
+
+type Option func(*HTTPChecker)
+
+func WithSecrets(secrets SecretLookup) Option {
+	return func(c *HTTPChecker) {
+		c.secrets = secrets
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *HTTPChecker) {
+		if userAgent != "" {
+			c.userAgent = userAgent
+		}
+	}
+}
+
+func WithRobots(ttl time.Duration) Option {
+	return func(c *HTTPChecker) {
+		c.robotsTTL = ttl
+	}
+}
+
 type HTTPChecker struct {
-	client *http.Client
+	client    *http.Client
+	secrets   SecretLookup
+	userAgent string
+	robotsTTL time.Duration
+	robots    *RobotsCache
+	hosts     *HostScheduler
+	probes    *probeCache
 }
 
-func NewHTTPChecker(timeout time.Duration) *HTTPChecker {
-	return &HTTPChecker{client: &http.Client{Timeout: timeout}}
+func NewHTTPChecker(timeout time.Duration, opts ...Option) *HTTPChecker {
+	c := &HTTPChecker{
+		client:    &http.Client{Timeout: timeout},
+		userAgent: DefaultUserAgent,
+		hosts:     NewHostScheduler(),
+		probes:    newProbeCache(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.robotsTTL > 0 {
+		c.robots = NewRobotsCache(c.client, c.userAgent, c.robotsTTL)
+	}
+	return c
 }
 
-func (c *HTTPChecker) Check(ctx context.Context, url string) models.LinkStatus {
+func (c *HTTPChecker) Check(ctx context.Context, url string, opts models.CheckOptions) models.LinkStatus {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return models.LinkStatus{URL: url, Status: models.StatusNotAvailable}
+		return models.LinkStatus{URL: url, Status: models.StatusNotAvailable, Result: models.ResultInvalidURL, CheckTime: clock.Now(), Error: err.Error()}
+	}
+
+	if err := c.admit(ctx, req); errors.Is(err, errRobotsDisallowed) {
+		return models.LinkStatus{URL: url, Status: models.StatusSkippedRobots, Result: models.ResultRobotsDisallowed, CheckTime: clock.Now()}
+	} else if err != nil {
+		return models.LinkStatus{URL: url, Status: models.StatusNotAvailable, Result: classifyError(err), CheckTime: clock.Now(), Error: err.Error()}
+	}
+
+	if err := c.prepareRequest(req, opts); err != nil {
+		return models.LinkStatus{URL: url, Status: models.StatusNotAvailable, Result: models.ResultConfigError, CheckTime: clock.Now(), Error: err.Error()}
 	}
 
+	timer := newPhaseTimer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.trace()))
+
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return models.LinkStatus{URL: url, Status: models.StatusNotAvailable}
+		return models.LinkStatus{
+			URL:       url,
+			Status:    models.StatusNotAvailable,
+			Result:    classifyError(err),
+			CheckTime: clock.Now(),
+			Error:     err.Error(),
+			Timing:    timer.timing(time.Now()),
+		}
 	}
-	resp.Body.Close()
+	defer resp.Body.Close()
 
 	status := models.StatusAvailable
 	if resp.StatusCode >= http.StatusBadRequest {
 		status = models.StatusNotAvailable
 	}
 
-	return models.LinkStatus{
-		URL:       url,
-		Status:    status,
-		CheckTime: clock.Now(),
+	success := resp.StatusCode < http.StatusMultipleChoices
+	inspect := (success && opts.DetectSoft404) || isChallengeStatus(resp.StatusCode)
+	track := success && opts.TrackChanges
+	resources := success && opts.CheckResources && isHTMLResponse(resp)
+	keep := success && opts.KeepPage && isHTMLResponse(resp)
+
+	var body []byte
+	if inspect || track || resources || keep {
+		limit := int64(maxInspectSize)
+		if track || resources || keep {
+			limit = maxPageSize
+		}
+		body, _ = io.ReadAll(io.LimitReader(resp.Body, limit))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxPageSize))
+
+	result := models.LinkStatus{
+		URL:        url,
+		Result:     classifyResponse(resp.StatusCode, resp.Request.URL.String() != req.URL.String()),
+		HTTPStatus: resp.StatusCode,
+		Timing:     timer.timing(time.Now()),
+	}
+	if opts.SecurityAudit {
+		result.Security = auditSecurity(resp)
+	}
+
+	switch {
+	case !inspect:
+	case detectWAF(resp, body):
+		status = models.StatusBlockedByWAF
+	case success && c.detectSoft404(ctx, resp, body, opts):
+		status = models.StatusSoft404
+		result.Result = models.ResultClientError
+	}
+
+	if resources {
+		result.Resources = c.checkResources(ctx, resp.Request.URL, body, opts)
+		if status == models.StatusAvailable && result.Resources != nil && result.Resources.Failed > 0 {
+			status = models.StatusBrokenResources
+			result.Result = models.ResultDegraded
+		}
+	}
+
+	if track {
+		result.ContentText = textExtract(body, resp.Header.Get("Content-Type"))
+		result.ContentHash = contentHash(result.ContentText)
+	}
+
+	if keep {
+		result.Page = &models.Page{
+			URL:         resp.Request.URL.String(),
+			StatusCode:  resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        body,
+		}
+	}
+
+	result.Status = status
+	result.CheckTime = clock.Now()
+	return result
+}
+
+var errRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+// admit applies robots.txt and its Crawl-delay to a request when robots mode
+// is on.
+func (c *HTTPChecker) admit(ctx context.Context, req *http.Request) error {
+	if c.robots == nil {
+		return nil
+	}
+	rules, err := c.robots.Rules(ctx, req.URL)
+	if err != nil {
+		return err
+	}
+	if !rules.Allowed(req.URL.RequestURI()) {
+		return errRobotsDisallowed
+	}
+	return c.hosts.Wait(ctx, req.URL.Host, rules.CrawlDelay)
+}
+
+func isHTMLResponse(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+func isChallengeStatus(code int) bool {
+	return code == http.StatusForbidden || code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+func (c *HTTPChecker) prepareRequest(req *http.Request, opts models.CheckOptions) error {
+	req.Header.Set("User-Agent", c.userAgent)
+	for _, h := range opts.Headers {
+		value := h.Value
+		if h.Secret != "" {
+			secret, err := c.lookupSecret(h.Secret)
+			if err != nil {
+				return err
+			}
+			value = secret
+		}
+		req.Header.Set(h.Name, value)
+	}
+
+	if opts.Auth == nil {
+		return nil
+	}
+
+	secret, err := c.lookupSecret(opts.Auth.Secret)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Auth.Type {
+	case models.AuthBasic:
+		req.SetBasicAuth(opts.Auth.Username, secret)
+	case models.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+secret)
+	default:
+		return fmt.Errorf("unsupported auth type %q", opts.Auth.Type)
+	}
+	return nil
+}
+
+func (c *HTTPChecker) lookupSecret(name string) (string, error) {
+	if c.secrets == nil {
+		return "", fmt.Errorf("secret %q requested but no secret store is configured", name)
+	}
+	value, ok := c.secrets.Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("secret %q not found", name)
 	}
+	return value, nil
 }
@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/whiterage/14-11-2025/pkg/models"
+)
+
+func TestHTTPChecker_Timing(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	checker := NewHTTPChecker(2 * time.Second)
+
+	first := checker.Check(context.Background(), srv.URL, models.CheckOptions{})
+	if first.Timing == nil {
+		t.Fatalf("timing not recorded")
+	}
+	if first.Timing.Reused || first.Timing.Connect <= 0 {
+		t.Fatalf("first request should open a connection: %+v", first.Timing)
+	}
+	if first.Timing.TTFB < 20 || first.Timing.Total < first.Timing.TTFB {
+		t.Fatalf("unexpected ttfb/total: %+v", first.Timing)
+	}
+
+	second := checker.Check(context.Background(), srv.URL, models.CheckOptions{})
+	if !second.Timing.Reused || second.Timing.Connect != 0 {
+		t.Fatalf("second request should reuse the connection: %+v", second.Timing)
+	}
+}
+
+func TestHTTPChecker_AppliesSecrets(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, basic := r.BasicAuth()
+		switch {
+		case r.URL.Path == "/basic" && basic && user == "bot" && pass == "hunter2":
+		case r.URL.Path == "/bearer" && r.Header.Get("Authorization") == "Bearer tok-42":
+		case r.URL.Path == "/header" && r.Header.Get("X-Api-Key") == "key-7" && r.Header.Get("X-Env") == "staging":
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	checker := NewHTTPChecker(2*time.Second, WithSecrets(staticSecrets{"pwd": "hunter2", "token": "tok-42", "api_key": "key-7"}))
+
+	tests := []struct {
+		path   string
+		opts   models.CheckOptions
+		result models.ResultClass
+	}{
+		{"/basic", models.CheckOptions{Auth: &models.Auth{Type: models.AuthBasic, Username: "bot", Secret: "pwd"}}, models.ResultOK},
+		{"/bearer", models.CheckOptions{Auth: &models.Auth{Type: models.AuthBearer, Secret: "token"}}, models.ResultOK},
+		{"/header", models.CheckOptions{Headers: []models.Header{{Name: "X-Api-Key", Secret: "api_key"}, {Name: "X-Env", Value: "staging"}}}, models.ResultOK},
+		{"/bearer", models.CheckOptions{Auth: &models.Auth{Type: models.AuthBearer, Secret: "missing"}}, models.ResultConfigError},
+	}
+	for _, tt := range tests {
+		got := checker.Check(context.Background(), srv.URL+tt.path, tt.opts)
+		if got.Result != tt.result {
+			t.Fatalf("%s: got %s/%s (%s), want %s", tt.path, got.Status, got.Result, got.Error, tt.result)
+		}
+	}
+
+	if got := NewHTTPChecker(2*time.Second).Check(context.Background(), srv.URL+"/bearer", tests[1].opts); got.Result != models.ResultConfigError {
+		t.Fatalf("checker without a secret store: got %s (%s)", got.Result, got.Error)
+	}
+}
+
+func TestHTTPChecker_FetchSitemapLimit(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := maxPageSize + 1<<20
+		if r.URL.Path == "/huge.xml" {
+			size = maxSitemapSize + 1
+		}
+		w.Write(make([]byte, size))
+	}))
+	defer srv.Close()
+
+	checker := NewHTTPChecker(5 * time.Second)
+
+	page, err := checker.FetchSitemap(context.Background(), srv.URL+"/sitemap.xml", models.CheckOptions{})
+	if err != nil || len(page.Body) != maxPageSize+1<<20 {
+		t.Fatalf("sitemap over the page limit truncated: %v", err)
+	}
+	if page, _ := checker.Fetch(context.Background(), srv.URL+"/sitemap.xml", models.CheckOptions{}); len(page.Body) != maxPageSize {
+		t.Fatalf("page read past its limit: %d bytes", len(page.Body))
+	}
+	if _, err := checker.FetchSitemap(context.Background(), srv.URL+"/huge.xml", models.CheckOptions{}); err == nil {
+		t.Fatalf("sitemap over the protocol limit accepted")
+	}
+}
@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 11, 14, 10, 7, 30, 0, time.UTC) // Friday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/5 * * * *", time.Date(2025, 11, 14, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, 11, 14, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2025, 11, 15, 9, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2025, 11, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"15,45 10 * * *", time.Date(2025, 11, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 11, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * 5", time.Date(2025, 11, 14, 12, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 11, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Fatalf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); !errors.Is(err, ErrInvalid) {
+			t.Fatalf("Parse(%q): expected ErrInvalid, got %v", expr, err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package htmllinks
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type Link struct {
+	URL      string
+	Text     string
+	Tag      string
+	Resource bool
+}
+
+var linkAttrs = map[string]string{
+	"a":      "href",
+	"area":   "href",
+	"link":   "href",
+	"img":    "src",
+	"script": "src",
+	"iframe": "src",
+	"source": "src",
+	"video":  "src",
+	"audio":  "src",
+	"embed":  "src",
+}
+
+// Extract returns absolute http(s) links found in the document, resolved
+// against base (or the document's own <base href>). Fragments are dropped.
+func Extract(base *url.URL, r io.Reader) ([]Link, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if href := findBase(doc); href != "" {
+		if parsed, err := base.Parse(href); err == nil {
+			base = parsed
+		}
+	}
+
+	var links []Link
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if link, ok := linkFromNode(base, n); ok {
+				links = append(links, link)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	return links, nil
+}
+
+func linkFromNode(base *url.URL, n *html.Node) (Link, bool) {
+	attr, ok := linkAttrs[n.Data]
+	if !ok {
+		return Link{}, false
+	}
+
+	raw := strings.TrimSpace(attrValue(n, attr))
+	if raw == "" || strings.HasPrefix(raw, "#") {
+		return Link{}, false
+	}
+
+	resolved, err := base.Parse(raw)
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return Link{}, false
+	}
+	resolved.Fragment = ""
+
+	link := Link{URL: resolved.String(), Tag: n.Data}
+	switch n.Data {
+	case "a", "area":
+		link.Text = strings.Join(strings.Fields(textContent(n)), " ")
+		if link.Text == "" {
+			link.Text = attrValue(n, "title")
+		}
+	case "link":
+		link.Resource = isResourceRel(attrValue(n, "rel"))
+	case "iframe":
+	default:
+		link.Resource = true
+	}
+	if n.Data == "img" {
+		link.Text = attrValue(n, "alt")
+	}
+	return link, true
+}
+
+func isResourceRel(rel string) bool {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		switch value {
+		case "stylesheet", "icon", "preload", "modulepreload", "manifest":
+			return true
+		}
+	}
+	return false
+}
+
+func findBase(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "base" {
+		return attrValue(n, "href")
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if href := findBase(child); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+func attrValue(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			sb.WriteString(node.Data)
+			sb.WriteByte(' ')
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
@@ -0,0 +1,48 @@
+package htmllinks
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	const page = `<html><head>
+<link rel="stylesheet" href="/css/site.css">
+<link rel="canonical" href="https://docs.example.com/guide">
+<script src="app.js"></script>
+</head><body>
+<a href="/install#step-1">  Install
+   guide </a>
+<a href="#top">top</a>
+<a href="mailto:team@example.com">mail</a>
+<a href="https://other.org/">External</a>
+<img src="img/logo.png" alt="Logo">
+</body></html>`
+
+	base, _ := url.Parse("https://docs.example.com/guide/")
+	links, err := Extract(base, strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+
+	want := []Link{
+		{URL: "https://docs.example.com/css/site.css", Tag: "link", Resource: true},
+		{URL: "https://docs.example.com/guide", Tag: "link"},
+		{URL: "https://docs.example.com/guide/app.js", Tag: "script", Resource: true},
+		{URL: "https://docs.example.com/install", Tag: "a", Text: "Install guide"},
+		{URL: "https://other.org/", Tag: "a", Text: "External"},
+		{URL: "https://docs.example.com/guide/img/logo.png", Tag: "img", Text: "Logo", Resource: true},
+	}
+
+	if len(links) != len(want) {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Fatalf("link %d: got %+v want %+v", i, links[i], want[i])
+		}
+	}
+}
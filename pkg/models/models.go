@@ -3,28 +3,433 @@ package models
 import "time"
 
 type LinkRequest struct {
-	Links []string `json:"links"`
+	Links     []string      `json:"links"`
+	Sitemap   string        `json:"sitemap,omitempty"`
+	Crawl     *CrawlOptions `json:"crawl,omitempty"`
+	Locations []string      `json:"locations,omitempty"`
+	Priority  string        `json:"priority,omitempty"`
+	// Deadline and MaxDurationSeconds bound the whole task; the earlier of
+	// the two wins. The duration counts from task creation.
+	Deadline           *time.Time `json:"deadline,omitempty"`
+	MaxDurationSeconds int        `json:"max_duration_seconds,omitempty"`
+	// CallbackURL receives the finished task, signed with the named secret.
+	CallbackURL    string `json:"callback_url,omitempty"`
+	CallbackSecret string `json:"callback_secret,omitempty"`
+	CheckOptions
 }
 
+type CrawlOptions struct {
+	MaxDepth int `json:"max_depth"`
+	MaxPages int `json:"max_pages"`
+}
+
+type CheckOptions struct {
+	Headers        []Header `json:"headers,omitempty"`
+	Auth           *Auth    `json:"auth,omitempty"`
+	DetectSoft404  bool     `json:"detect_soft_404,omitempty"`
+	TrackChanges   bool     `json:"track_changes,omitempty"`
+	TrackText      bool     `json:"track_text,omitempty"`
+	SecurityAudit  bool     `json:"security_audit,omitempty"`
+	CheckResources bool     `json:"check_resources,omitempty"`
+	StripTracking  bool     `json:"strip_tracking_params,omitempty"`
+	// KeepPage asks an HTTP check to return the HTML it downloaded in
+	// LinkStatus.Page, so the crawler does not fetch it again.
+	KeepPage bool `json:"-"`
+
+	Transaction *Transaction `json:"transaction,omitempty"`
+}
+
+// Transaction is a scripted multi-step check. Step URLs are resolved against
+// the checked link and may use {{name}} placeholders filled by earlier
+// captures.
+type Transaction struct {
+	Steps []Step `json:"steps"`
+}
+
+type Step struct {
+	Name    string      `json:"name,omitempty"`
+	Method  string      `json:"method,omitempty"`
+	URL     string      `json:"url,omitempty"`
+	Headers []Header    `json:"headers,omitempty"`
+	Form    []FormField `json:"form,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	Capture []Capture   `json:"capture,omitempty"`
+	Expect  *Expect     `json:"expect,omitempty"`
+}
+
+type FormField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// Capture stores a value for later steps: a cookie from the session jar, a
+// response header or the first group of a regular expression over the body.
+type Capture struct {
+	Name   string `json:"name"`
+	Cookie string `json:"cookie,omitempty"`
+	Header string `json:"header,omitempty"`
+	Regex  string `json:"regex,omitempty"`
+}
+
+type Expect struct {
+	Status      int      `json:"status,omitempty"`
+	Contains    []string `json:"contains,omitempty"`
+	NotContains []string `json:"not_contains,omitempty"`
+}
+
+type StepResult struct {
+	Name       string  `json:"name"`
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Duration   float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type Header struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Secret string `json:"secret,omitempty"`
+}
+
+type Auth struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Secret   string `json:"secret"`
+}
+
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+)
+
 type LinkStatus struct {
-	URL       string    `json:"url"`
-	Status    string    `json:"status"`
-	CheckTime time.Time `json:"check_time,omitempty"`
+	URL          string      `json:"url"`
+	Inputs       []string    `json:"inputs,omitempty"`
+	Location     string      `json:"location,omitempty"`
+	Status       string      `json:"status"`
+	Availability string      `json:"availability,omitempty"`
+	Result       ResultClass `json:"result,omitempty"`
+	HTTPStatus   int         `json:"http_status,omitempty"`
+	CheckTime    time.Time   `json:"check_time,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	Cached       bool        `json:"cached,omitempty"`
+
+	Referrer   string `json:"referrer,omitempty"`
+	AnchorText string `json:"anchor_text,omitempty"`
+	Depth      int    `json:"depth,omitempty"`
+	LastMod    string `json:"lastmod,omitempty"`
+
+	ContentHash string `json:"content_hash,omitempty"`
+	Changed     bool   `json:"changed,omitempty"`
+	Diff        string `json:"diff,omitempty"`
+	ContentText string `json:"-"`
+	Page        *Page  `json:"-"`
+
+	Timing  *Timing           `json:"timing,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+
+	Security  *SecurityReport `json:"security,omitempty"`
+	Resources *ResourceReport `json:"resources,omitempty"`
+	Steps     []StepResult    `json:"steps,omitempty"`
+}
+
+type ResourceReport struct {
+	Total        int               `json:"total"`
+	Failed       int               `json:"failed"`
+	Truncated    bool              `json:"truncated,omitempty"`
+	Broken       []ResourceFailure `json:"broken,omitempty"`
+	MixedContent []string          `json:"mixed_content,omitempty"`
+}
+
+type ResourceFailure struct {
+	URL        string `json:"url"`
+	Tag        string `json:"tag"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type SecurityReport struct {
+	Grade    string            `json:"grade"`
+	Score    int               `json:"score"`
+	Headers  map[string]string `json:"headers"`
+	Cookies  []CookieAudit     `json:"cookies,omitempty"`
+	Findings []string          `json:"findings,omitempty"`
+}
+
+type CookieAudit struct {
+	Name     string `json:"name"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"http_only"`
+	SameSite string `json:"same_site,omitempty"`
+}
+
+type Timing struct {
+	DNS      float64 `json:"dns_ms"`
+	Connect  float64 `json:"connect_ms"`
+	TLS      float64 `json:"tls_ms"`
+	TTFB     float64 `json:"ttfb_ms"`
+	Transfer float64 `json:"transfer_ms"`
+	Total    float64 `json:"total_ms"`
+	Reused   bool    `json:"reused"`
+}
+
+type ContentSnapshot struct {
+	Hash      string    `json:"hash"`
+	Text      string    `json:"text,omitempty"`
+	CheckTime time.Time `json:"check_time"`
+	TaskID    int       `json:"links_num"`
+}
+
+const (
+	StatusPending           = "pending"
+	StatusProcessing        = "processing"
+	StatusDone              = "done"
+	StatusAvailable         = "available"
+	StatusNotAvailable      = "not_available"
+	StatusSkippedRobots     = "skipped_robots"
+	StatusSoft404           = "soft_404"
+	StatusBlockedByWAF      = "blocked_by_waf"
+	StatusUnsupportedScheme = "unsupported_scheme"
+	StatusBrokenResources   = "broken_resources"
+	StatusCancelled         = "cancelled"
+	StatusDeadlineExceeded  = "deadline_exceeded"
+	StatusPartial           = "partial"
+)
+
+// ResultClass explains why a check ended the way it did; Status keeps the
+// coarse outcome used by existing clients.
+type ResultClass string
+
+const (
+	ResultOK                ResultClass = "ok"
+	ResultRedirect          ResultClass = "redirect"
+	ResultClientError       ResultClass = "client_error"
+	ResultAuthRequired      ResultClass = "auth_required"
+	ResultServerError       ResultClass = "server_error"
+	ResultTimeout           ResultClass = "timeout"
+	ResultDNSError          ResultClass = "dns_error"
+	ResultTLSError          ResultClass = "tls_error"
+	ResultConnectionRefused ResultClass = "connection_refused"
+	ResultNetworkError      ResultClass = "network_error"
+	ResultInvalidURL        ResultClass = "invalid_url"
+	ResultDegraded          ResultClass = "degraded"
+	ResultNoAgent           ResultClass = "no_agent"
+	ResultRobotsDisallowed  ResultClass = "robots_disallowed"
+	// ResultConfigError means the check was not sent because a secret it
+	// needs is missing from the store.
+	ResultConfigError ResultClass = "config_error"
+	// ResultUnclassified marks results stored before classification existed.
+	ResultUnclassified ResultClass = "unclassified"
+)
+
+// AvailabilityOf maps a link status to the original available/not_available
+// pair. Links that were never checked have no availability, and links
+// skipped by robots.txt keep their own.
+func AvailabilityOf(status string) string {
+	switch status {
+	case StatusPending, StatusProcessing, StatusCancelled, StatusDeadlineExceeded, "":
+		return ""
+	case StatusAvailable, StatusBrokenResources:
+		return StatusAvailable
+	case StatusSkippedRobots:
+		return StatusSkippedRobots
+	default:
+		return StatusNotAvailable
+	}
 }
 
 const (
-	StatusPending      = "pending"
-	StatusProcessing   = "processing"
-	StatusDone         = "done"
-	StatusAvailable    = "available"
-	StatusNotAvailable = "not_available"
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+const (
+	TaskTypeLinks   = "links"
+	TaskTypeCrawl   = "crawl"
+	TaskTypeSitemap = "sitemap"
 )
 
 type Task struct {
-	ID        int          `json:"links_num"`
-	CreatedAt time.Time    `json:"created_at"`
-	Status    string       `json:"status"`
-	Results   []LinkStatus `json:"results"`
+	ID        int           `json:"links_num"`
+	Type      string        `json:"type,omitempty"`
+	Priority  string        `json:"priority,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	Status    string        `json:"status"`
+	Options   CheckOptions  `json:"options"`
+	Crawl     *CrawlOptions `json:"crawl,omitempty"`
+	Sitemap   string        `json:"sitemap,omitempty"`
+	Locations []string      `json:"locations,omitempty"`
+	Budget    *Budget       `json:"budget,omitempty"`
+	MonitorID int           `json:"monitor_id,omitempty"`
+	Callback  *Callback     `json:"callback,omitempty"`
+	Results   []LinkStatus  `json:"results"`
+}
+
+// Clone returns a copy of the task that can be changed independently.
+// Check details of results are shared: they are replaced, never modified in
+// place.
+func (t *Task) Clone() *Task {
+	c := *t
+	c.Results = append([]LinkStatus(nil), t.Results...)
+	c.Locations = append([]string(nil), t.Locations...)
+	if t.Budget != nil {
+		budget := *t.Budget
+		c.Budget = &budget
+	}
+	if t.Callback != nil {
+		callback := *t.Callback
+		c.Callback = &callback
+	}
+	return &c
+}
+
+// Callback is the webhook of a task and its delivery state. Secret is the
+// name of the signing secret, never its value.
+type Callback struct {
+	URL            string     `json:"url"`
+	Secret         string     `json:"secret"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts,omitempty"`
+	NextAttempt    *time.Time `json:"next_attempt,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+const (
+	CallbackPending   = "pending"
+	CallbackDelivered = "delivered"
+	CallbackFailed    = "failed"
+)
+
+// Budget is the time limit of a task. Used is filled in when the task
+// finishes.
+type Budget struct {
+	Deadline time.Time `json:"deadline"`
+	Used     float64   `json:"used_ms,omitempty"`
+}
+
+// LocationLocal runs checks on the server itself when a task also asks for
+// remote agent locations.
+const LocationLocal = "local"
+
+type AgentRegisterRequest struct {
+	Location string `json:"location"`
+}
+
+type AgentRegisterResponse struct {
+	AgentID         string `json:"agent_id"`
+	LeaseTTLSeconds int    `json:"lease_ttl_seconds"`
+}
+
+type AgentJob struct {
+	LeaseID string       `json:"lease_id"`
+	URL     string       `json:"url"`
+	Options CheckOptions `json:"options"`
+}
+
+type AgentPollResponse struct {
+	Jobs []AgentJob `json:"jobs"`
+}
+
+type AgentResult struct {
+	LeaseID string     `json:"lease_id"`
+	Result  LinkStatus `json:"result"`
+	// ContentText is sent for track_text jobs; LinkStatus never serializes it.
+	ContentText string `json:"content_text,omitempty"`
+}
+
+type AgentResultsRequest struct {
+	Results []AgentResult `json:"results"`
+}
+
+type AgentResultsResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// MonitorRequest creates or replaces a monitor: a saved link set checked on
+// a schedule. Exactly one of IntervalSeconds and Cron is set; every run is
+// delayed by a random jitter of up to JitterSeconds.
+type MonitorRequest struct {
+	Name            string `json:"name"`
+	IntervalSeconds int    `json:"interval_seconds,omitempty"`
+	Cron            string `json:"cron,omitempty"`
+	JitterSeconds   int    `json:"jitter_seconds,omitempty"`
+	Paused          bool   `json:"paused,omitempty"`
+	LinkRequest
+}
+
+type Monitor struct {
+	ID int `json:"id"`
+	MonitorRequest
+	CreatedAt  time.Time  `json:"created_at"`
+	NextRun    *time.Time `json:"next_run,omitempty"`
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	LastTaskID int        `json:"last_links_num,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// Clone returns a copy of the monitor that shares no mutable state with it.
+func (m *Monitor) Clone() *Monitor {
+	c := *m
+	c.Links = append([]string(nil), m.Links...)
+	c.Locations = append([]string(nil), m.Locations...)
+	c.Headers = append([]Header(nil), m.Headers...)
+	c.Crawl = clonePtr(m.Crawl)
+	c.Auth = clonePtr(m.Auth)
+	c.Deadline = clonePtr(m.Deadline)
+	c.NextRun = clonePtr(m.NextRun)
+	c.LastRun = clonePtr(m.LastRun)
+	return &c
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// IdempotencyKey maps the Idempotency-Key of a POST /links request to the
+// task it created.
+type IdempotencyKey struct {
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	TaskID      int       `json:"links_num"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PoolStatus is served by the admin API.
+type PoolStatus struct {
+	Workers   int              `json:"workers"`
+	Backlog   int              `json:"backlog"`
+	LatencyMs float64          `json:"latency_ms"`
+	Autoscale *AutoscaleBounds `json:"autoscale,omitempty"`
+}
+
+type AutoscaleBounds struct {
+	Min int `json:"min_workers"`
+	Max int `json:"max_workers"`
+}
+
+// PoolUpdate sets the pool size, or the autoscaler bounds when autoscaling
+// is enabled.
+type PoolUpdate struct {
+	Workers    int `json:"workers,omitempty"`
+	MinWorkers int `json:"min_workers,omitempty"`
+	MaxWorkers int `json:"max_workers,omitempty"`
+}
+
+type Page struct {
+	URL         string
+	StatusCode  int
+	ContentType string
+	Body        []byte
 }
 
 type ReportRequest struct {
@@ -3,6 +3,7 @@ package pdf
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
@@ -11,6 +12,8 @@ import (
 	"github.com/whiterage/14-11-2025/pkg/models"
 )
 
+const maxDiffLines = 40
+
 func BuildReport(tasks []*models.Task) ([]byte, error) {
 	doc := gofpdf.New("P", "mm", "A4", "")
 	doc.SetTitle("Links status report", false)
@@ -45,22 +48,214 @@ func writeTaskBlock(doc *gofpdf.Fpdf, task *models.Task) {
 
 	doc.SetFont("Arial", "", 10)
 	doc.Cell(0, 5, fmt.Sprintf("Created at: %s", task.CreatedAt.Format(time.RFC3339)))
+	if task.Budget != nil {
+		doc.Ln(5)
+		doc.Cell(0, 5, fmt.Sprintf("Deadline: %s, used: %.0f ms", task.Budget.Deadline.Format(time.RFC3339), task.Budget.Used))
+	}
 	doc.Ln(8)
 
 	doc.SetFont("Arial", "B", 11)
-	doc.CellFormat(90, 7, "URL", "1", 0, "", false, 0, "")
-	doc.CellFormat(40, 7, "Status", "1", 0, "", false, 0, "")
-	doc.CellFormat(0, 7, "Checked At", "1", 1, "", false, 0, "")
+	doc.CellFormat(64, 7, "URL", "1", 0, "", false, 0, "")
+	doc.CellFormat(28, 7, "Status", "1", 0, "", false, 0, "")
+	doc.CellFormat(44, 7, "Checked At", "1", 0, "", false, 0, "")
+	doc.CellFormat(0, 7, "Timing, ms", "1", 1, "", false, 0, "")
 
-	doc.SetFont("Arial", "", 10)
 	for _, res := range task.Results {
 		checked := "-"
 		if !res.CheckTime.IsZero() {
 			checked = res.CheckTime.Format(time.RFC3339)
 		}
 
-		doc.CellFormat(90, 6, res.URL, "1", 0, "", false, 0, "")
-		doc.CellFormat(40, 6, res.Status, "1", 0, "", false, 0, "")
-		doc.CellFormat(0, 6, checked, "1", 1, "", false, 0, "")
+		label := res.URL
+		if res.Location != "" {
+			label += " @" + res.Location
+		}
+
+		doc.SetFont("Arial", "", 9)
+		doc.CellFormat(64, 6, label, "1", 0, "", false, 0, "")
+		doc.CellFormat(28, 6, res.Status, "1", 0, "", false, 0, "")
+		doc.CellFormat(44, 6, checked, "1", 0, "", false, 0, "")
+		doc.SetFont("Arial", "", 7)
+		doc.CellFormat(0, 6, formatTiming(res.Timing), "1", 1, "", false, 0, "")
+	}
+
+	if task.Type == models.TaskTypeCrawl {
+		writeBrokenLinks(doc, task)
+	}
+	writeContentChanges(doc, task)
+	writeSecurityFindings(doc, task)
+	writeBrokenResources(doc, task)
+	writeTransactions(doc, task)
+}
+
+func writeTransactions(doc *gofpdf.Fpdf, task *models.Task) {
+	if task.Options.Transaction == nil {
+		return
+	}
+
+	doc.Ln(4)
+	doc.SetFont("Arial", "B", 11)
+	doc.Cell(0, 7, "Transaction steps")
+	doc.Ln(8)
+
+	for _, res := range task.Results {
+		if len(res.Steps) == 0 {
+			continue
+		}
+		doc.SetFont("Arial", "B", 10)
+		doc.CellFormat(0, 6, fmt.Sprintf("%s: %s", res.URL, res.Status), "1", 1, "", false, 0, "")
+
+		doc.SetFont("Arial", "", 8)
+		for _, step := range res.Steps {
+			line := fmt.Sprintf("  %s  %s %s  %d  %.0f ms", step.Name, step.Method, step.URL, step.StatusCode, step.Duration)
+			if step.Error != "" {
+				line += "  FAILED: " + step.Error
+			}
+			doc.CellFormat(0, 5, line, "LR", 1, "", false, 0, "")
+		}
+		doc.CellFormat(0, 0, "", "T", 1, "", false, 0, "")
+	}
+}
+
+func writeBrokenResources(doc *gofpdf.Fpdf, task *models.Task) {
+	var affected []models.LinkStatus
+	for _, res := range task.Results {
+		if res.Resources != nil && res.Resources.Failed > 0 {
+			affected = append(affected, res)
+		}
+	}
+	if len(affected) == 0 {
+		return
+	}
+
+	doc.Ln(4)
+	doc.SetFont("Arial", "B", 11)
+	doc.Cell(0, 7, fmt.Sprintf("Pages with broken resources: %d", len(affected)))
+	doc.Ln(8)
+
+	for _, res := range affected {
+		doc.SetFont("Arial", "B", 10)
+		doc.CellFormat(0, 6, fmt.Sprintf("%s (%d of %d failed)", res.URL, res.Resources.Failed, res.Resources.Total), "1", 1, "", false, 0, "")
+
+		doc.SetFont("Arial", "", 8)
+		for _, broken := range res.Resources.Broken {
+			doc.CellFormat(0, 5, fmt.Sprintf("  - %s <%s>: %s", broken.URL, broken.Tag, broken.Error), "LR", 1, "", false, 0, "")
+		}
+		for _, mixed := range res.Resources.MixedContent {
+			doc.CellFormat(0, 5, fmt.Sprintf("  - %s: mixed content", mixed), "LR", 1, "", false, 0, "")
+		}
+		doc.CellFormat(0, 0, "", "T", 1, "", false, 0, "")
+	}
+}
+
+func writeSecurityFindings(doc *gofpdf.Fpdf, task *models.Task) {
+	var audited []models.LinkStatus
+	for _, res := range task.Results {
+		if res.Security != nil {
+			audited = append(audited, res)
+		}
+	}
+	if len(audited) == 0 {
+		return
+	}
+
+	doc.Ln(4)
+	doc.SetFont("Arial", "B", 11)
+	doc.Cell(0, 7, "Security headers audit")
+	doc.Ln(8)
+
+	doc.SetFont("Arial", "B", 10)
+	doc.CellFormat(120, 7, "URL", "1", 0, "", false, 0, "")
+	doc.CellFormat(20, 7, "Grade", "1", 0, "C", false, 0, "")
+	doc.CellFormat(0, 7, "Score", "1", 1, "C", false, 0, "")
+
+	for _, res := range audited {
+		doc.SetFont("Arial", "", 9)
+		doc.CellFormat(120, 6, res.URL, "1", 0, "", false, 0, "")
+		doc.CellFormat(20, 6, res.Security.Grade, "1", 0, "C", false, 0, "")
+		doc.CellFormat(0, 6, fmt.Sprintf("%d", res.Security.Score), "1", 1, "C", false, 0, "")
+
+		if len(res.Security.Findings) == 0 {
+			continue
+		}
+		doc.SetFont("Arial", "", 8)
+		for _, finding := range res.Security.Findings {
+			doc.CellFormat(0, 5, "  - "+finding, "LR", 1, "", false, 0, "")
+		}
+		doc.CellFormat(0, 0, "", "T", 1, "", false, 0, "")
+	}
+}
+
+func formatTiming(t *models.Timing) string {
+	if t == nil {
+		return "-"
+	}
+	if t.Reused {
+		return fmt.Sprintf("reused ttfb %.0f dl %.0f = %.0f", t.TTFB, t.Transfer, t.Total)
+	}
+	return fmt.Sprintf("dns %.0f tcp %.0f tls %.0f ttfb %.0f dl %.0f = %.0f", t.DNS, t.Connect, t.TLS, t.TTFB, t.Transfer, t.Total)
+}
+
+func writeContentChanges(doc *gofpdf.Fpdf, task *models.Task) {
+	var changed []models.LinkStatus
+	for _, res := range task.Results {
+		if res.Changed {
+			changed = append(changed, res)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	doc.Ln(4)
+	doc.SetFont("Arial", "B", 11)
+	doc.Cell(0, 7, fmt.Sprintf("Content changed since previous check: %d", len(changed)))
+	doc.Ln(8)
+
+	for _, res := range changed {
+		doc.SetFont("Arial", "B", 10)
+		doc.MultiCell(0, 5, res.URL, "", "", false)
+
+		diff := res.Diff
+		if diff == "" {
+			diff = "content hash changed (text extract not stored)"
+		}
+		lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+		if len(lines) > maxDiffLines {
+			lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more lines", len(lines)-maxDiffLines))
+		}
+
+		doc.SetFont("Courier", "", 8)
+		doc.MultiCell(0, 4, strings.Join(lines, "\n"), "1", "", false)
+		doc.Ln(2)
+	}
+}
+
+func writeBrokenLinks(doc *gofpdf.Fpdf, task *models.Task) {
+	var broken []models.LinkStatus
+	for _, res := range task.Results {
+		if res.Status == models.StatusNotAvailable && res.Referrer != "" {
+			broken = append(broken, res)
+		}
+	}
+	if len(broken) == 0 {
+		return
+	}
+
+	doc.Ln(4)
+	doc.SetFont("Arial", "B", 11)
+	doc.Cell(0, 7, fmt.Sprintf("Broken links found while crawling: %d", len(broken)))
+	doc.Ln(8)
+
+	doc.SetFont("Arial", "B", 10)
+	doc.CellFormat(70, 7, "Broken URL", "1", 0, "", false, 0, "")
+	doc.CellFormat(70, 7, "Found on", "1", 0, "", false, 0, "")
+	doc.CellFormat(0, 7, "Anchor text", "1", 1, "", false, 0, "")
+
+	doc.SetFont("Arial", "", 9)
+	for _, res := range broken {
+		doc.CellFormat(70, 6, res.URL, "1", 0, "", false, 0, "")
+		doc.CellFormat(70, 6, res.Referrer, "1", 0, "", false, 0, "")
+		doc.CellFormat(0, 6, res.AnchorText, "1", 1, "", false, 0, "")
 	}
 }
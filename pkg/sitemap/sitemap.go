@@ -0,0 +1,75 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+const maxDecodedSize = 50 << 20
+
+var ErrUnknownFormat = errors.New("document is neither urlset nor sitemapindex")
+
+type Entry struct {
+	Loc     string
+	LastMod string
+}
+
+type Document struct {
+	URLs     []Entry
+	Sitemaps []string
+}
+
+type xmlLoc struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type xmlDocument struct {
+	XMLName  xml.Name
+	URLs     []xmlLoc `xml:"url"`
+	Sitemaps []xmlLoc `xml:"sitemap"`
+}
+
+// Parse reads a sitemap or sitemap index; gzip-compressed input is detected
+// by its magic bytes, so .xml.gz files work regardless of Content-Type.
+func Parse(data []byte) (*Document, error) {
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		data, err = io.ReadAll(io.LimitReader(zr, maxDecodedSize))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var raw xmlDocument
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	switch raw.XMLName.Local {
+	case "urlset":
+		for _, u := range raw.URLs {
+			if loc := strings.TrimSpace(u.Loc); loc != "" {
+				doc.URLs = append(doc.URLs, Entry{Loc: loc, LastMod: strings.TrimSpace(u.LastMod)})
+			}
+		}
+	case "sitemapindex":
+		for _, sm := range raw.Sitemaps {
+			if loc := strings.TrimSpace(sm.Loc); loc != "" {
+				doc.Sitemaps = append(doc.Sitemaps, loc)
+			}
+		}
+	default:
+		return nil, ErrUnknownFormat
+	}
+	return doc, nil
+}
@@ -0,0 +1,53 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+func TestParse_URLSet(t *testing.T) {
+	t.Parallel()
+
+	const body = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/ </loc><lastmod>2025-10-01</lastmod></url>
+  <url><loc>https://example.com/about</loc></url>
+</urlset>`
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(body))
+	zw.Close()
+
+	for name, data := range map[string][]byte{"plain": []byte(body), "gzip": buf.Bytes()} {
+		doc, err := Parse(data)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", name, err)
+		}
+		if len(doc.URLs) != 2 || doc.URLs[0].Loc != "https://example.com/" || doc.URLs[0].LastMod != "2025-10-01" {
+			t.Fatalf("%s: unexpected entries: %+v", name, doc.URLs)
+		}
+	}
+}
+
+func TestParse_Index(t *testing.T) {
+	t.Parallel()
+
+	const body = `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-1.xml.gz</loc></sitemap>
+  <sitemap><loc>https://example.com/sitemap-2.xml</loc></sitemap>
+</sitemapindex>`
+
+	doc, err := Parse([]byte(body))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(doc.Sitemaps) != 2 || len(doc.URLs) != 0 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+
+	if _, err := Parse([]byte(`<html></html>`)); err == nil {
+		t.Fatalf("expected error for non-sitemap document")
+	}
+}
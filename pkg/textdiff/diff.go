@@ -0,0 +1,185 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	contextLines = 3
+	maxCells     = 4_000_000
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff of two texts compared line by line, or an
+// empty string when they are equal.
+func Unified(from, to, fromName, toName string) string {
+	if from == to {
+		return ""
+	}
+
+	a, b := splitLines(from), splitLines(to)
+	ops, ok := diffLines(a, b)
+	if !ok {
+		return fmt.Sprintf("--- %s\n+++ %s\n@@ texts too large to diff (%d vs %d lines) @@\n", fromName, toName, len(a), len(b))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	writeHunks(&sb, ops)
+	return sb.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func diffLines(a, b []string) ([]op, bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(midA)+1)*(len(midB)+1) > maxCells {
+		return nil, false
+	}
+
+	// lcs[i][j] holds the LCS length of midA[i:] and midB[j:].
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{opEqual, line})
+	}
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			ops = append(ops, op{opEqual, midA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{opDelete, midA[i]})
+			i++
+		default:
+			ops = append(ops, op{opInsert, midB[j]})
+			j++
+		}
+	}
+	for ; i < len(midA); i++ {
+		ops = append(ops, op{opDelete, midA[i]})
+	}
+	for ; j < len(midB); j++ {
+		ops = append(ops, op{opInsert, midB[j]})
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{opEqual, line})
+	}
+	return ops, true
+}
+
+func writeHunks(sb *strings.Builder, ops []op) {
+	// Line numbers (1-based) in the old and new text for every op.
+	oldLine, newLine := make([]int, len(ops)), make([]int, len(ops))
+	o, n := 1, 1
+	for i, e := range ops {
+		oldLine[i], newLine[i] = o, n
+		if e.kind != opInsert {
+			o++
+		}
+		if e.kind != opDelete {
+			n++
+		}
+	}
+
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start == len(ops) {
+			return
+		}
+
+		from := max(start-contextLines, 0)
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				end = min(end+contextLines, len(ops))
+				break
+			}
+			end = run
+		}
+
+		oldCount, newCount := 0, 0
+		for _, e := range ops[from:end] {
+			if e.kind != opInsert {
+				oldCount++
+			}
+			if e.kind != opDelete {
+				newCount++
+			}
+		}
+		oldStart, newStart := oldLine[from], newLine[from]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, e := range ops[from:end] {
+			switch e.kind {
+			case opEqual:
+				sb.WriteString(" ")
+			case opDelete:
+				sb.WriteString("-")
+			case opInsert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(e.line)
+			sb.WriteString("\n")
+		}
+		start = end
+	}
+}
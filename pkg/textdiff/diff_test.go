@@ -0,0 +1,34 @@
+package textdiff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	t.Parallel()
+
+	from := "Terms of service\nSection 1\nSection 2\nSection 3\nSection 4\nSection 5\nSection 6\nSection 7\nSection 8\nContact us\n"
+	to := "Terms of service\nSection 1\nSection 2 (revised)\nSection 3\nSection 4\nSection 5\nSection 6\nSection 7\nSection 8\nContact us\nImprint\n"
+
+	want := `--- old
++++ new
+@@ -1,6 +1,6 @@
+ Terms of service
+ Section 1
+-Section 2
++Section 2 (revised)
+ Section 3
+ Section 4
+ Section 5
+@@ -8,3 +8,4 @@
+ Section 7
+ Section 8
+ Contact us
++Imprint
+`
+
+	if got := Unified(from, to, "old", "new"); got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if got := Unified(from, from, "old", "new"); got != "" {
+		t.Fatalf("expected empty diff for equal texts, got %q", got)
+	}
+}
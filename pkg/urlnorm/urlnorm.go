@@ -0,0 +1,160 @@
+package urlnorm
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	ErrEmpty       = errors.New("empty url")
+	ErrInvalidHost = errors.New("invalid url host")
+)
+
+type Options struct {
+	// StripTracking removes utm_* and click-id query parameters.
+	StripTracking bool
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+var trackingParams = map[string]bool{
+	"fbclid":    true,
+	"gclid":     true,
+	"dclid":     true,
+	"msclkid":   true,
+	"yclid":     true,
+	"mc_cid":    true,
+	"mc_eid":    true,
+	"_openstat": true,
+}
+
+// schemePrefix matches a leading scheme; "://" later in the URL, e.g. in a
+// query parameter, does not count. opaqueScheme matches schemes without an
+// authority, such as mailto: or tel:, and hostPort the "host:port" form that
+// would otherwise read as one.
+var (
+	schemePrefix = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+	opaqueScheme = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+	hostPort     = regexp.MustCompile(`^[^:/?#]+:[0-9]*(?:[/?#]|$)`)
+)
+
+var hostProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.StrictDomainName(false))
+
+// Canonical returns the form of raw used to compare and check links:
+// https:// is assumed when no scheme is given, the host is lowercased and
+// converted to punycode, default ports and fragments are dropped and a bare
+// root path is removed. URLs with an opaque scheme (mailto:, tel:) are only
+// trimmed and get their scheme lowercased.
+func Canonical(raw string, opts Options) (string, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return "", ErrEmpty
+	}
+	if !schemePrefix.MatchString(value) {
+		if opaqueScheme.MatchString(value) && !hostPort.MatchString(value) {
+			parsed, err := url.Parse(value)
+			if err != nil {
+				return "", err
+			}
+			parsed.Scheme = strings.ToLower(parsed.Scheme)
+			return parsed.String(), nil
+		}
+		value = "https://" + value
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Host == "" {
+		return "", ErrInvalidHost
+	}
+
+	host, err := canonicalHost(parsed.Hostname())
+	if err != nil {
+		return "", err
+	}
+	port := parsed.Port()
+	if port == defaultPorts[parsed.Scheme] {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	parsed.Host = host
+
+	parsed.Fragment, parsed.RawFragment = "", ""
+	if opts.StripTracking {
+		parsed.RawQuery = stripTracking(parsed.RawQuery)
+	}
+	parsed.ForceQuery = false
+
+	switch {
+	case parsed.Path == "/" && parsed.RawQuery == "":
+		parsed.Path, parsed.RawPath = "", ""
+	case parsed.Path == "" && parsed.RawQuery != "":
+		parsed.Path = "/"
+	}
+	return parsed.String(), nil
+}
+
+func canonicalHost(host string) (string, error) {
+	if host == "" {
+		return "", ErrInvalidHost
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	ascii, err := hostProfile.ToASCII(host)
+	if err == nil {
+		return ascii, nil
+	}
+	// Hostnames such as "r3---cache.example.com" break the IDNA hyphen
+	// rules but resolve fine, so plain ASCII names are only lowercased.
+	for i := 0; i < len(host); i++ {
+		if host[i] >= 0x80 {
+			return "", ErrInvalidHost
+		}
+	}
+	return strings.ToLower(host), nil
+}
+
+// stripTracking drops tracking parameters while keeping the order of the
+// remaining ones, so the canonical URL stays close to the input.
+func stripTracking(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	kept := make([]string, 0, strings.Count(rawQuery, "&")+1)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(pair, "=")
+		if key, err := url.QueryUnescape(name); err == nil {
+			name = key
+		}
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "utm_") || trackingParams[name] {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
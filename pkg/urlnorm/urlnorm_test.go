@@ -0,0 +1,53 @@
+package urlnorm
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		opts  Options
+		want  string
+	}{
+		{"example.com", Options{}, "https://example.com"},
+		{"  Example.COM/ ", Options{}, "https://example.com"},
+		{"https://example.com:443/#x", Options{}, "https://example.com"},
+		{"http://example.com:80/a/b?x=1#frag", Options{}, "http://example.com/a/b?x=1"},
+		{"http://example.com:8080/", Options{}, "http://example.com:8080"},
+		{"президент.рф", Options{}, "https://xn--d1abbgf6aiiy.xn--p1ai"},
+		{"https://ПРЕЗИДЕНТ.РФ/новости", Options{}, "https://xn--d1abbgf6aiiy.xn--p1ai/%D0%BD%D0%BE%D0%B2%D0%BE%D1%81%D1%82%D0%B8"},
+		{"https://r3---sn-abc.example.com/", Options{}, "https://r3---sn-abc.example.com"},
+		{"https://[::1]:443/", Options{}, "https://[::1]"},
+		{"wss://example.com:443/socket", Options{}, "wss://example.com/socket"},
+		{"grpc://Example.com:50051/pkg.Service", Options{}, "grpc://example.com:50051/pkg.Service"},
+		{"https://example.com/?utm_source=x&id=7&fbclid=abc", Options{}, "https://example.com/?utm_source=x&id=7&fbclid=abc"},
+		{"https://example.com/?utm_source=x&id=7&fbclid=abc", Options{StripTracking: true}, "https://example.com/?id=7"},
+		{"https://example.com/page?UTM_Medium=mail&gclid=1", Options{StripTracking: true}, "https://example.com/page"},
+		{"https://example.com?b=2&a=1", Options{}, "https://example.com/?b=2&a=1"},
+		{"example.com/login?next=https://example.com/a", Options{}, "https://example.com/login?next=https://example.com/a"},
+		{"localhost:8080/health", Options{}, "https://localhost:8080/health"},
+		{"example.com:8443", Options{}, "https://example.com:8443"},
+		{"mailto:foo@example.com", Options{}, "mailto:foo@example.com"},
+		{"MAILTO:foo@example.com", Options{}, "mailto:foo@example.com"},
+		{"tel:+123", Options{}, "tel:+123"},
+		{"javascript:void(0)", Options{}, "javascript:void(0)"},
+		{"smtp:mail.example.com:25", Options{}, "smtp:mail.example.com:25"},
+	}
+
+	for _, tt := range tests {
+		got, err := Canonical(tt.input, tt.opts)
+		if err != nil {
+			t.Fatalf("Canonical(%q): %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Fatalf("Canonical(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "   ", "https://", "https://bad host.com", "https://пример\u2028.рф"} {
+		if _, err := Canonical(input, Options{}); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}